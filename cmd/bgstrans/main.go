@@ -0,0 +1,378 @@
+// Command bgstrans transforms coordinate files offline, with the same configuration as the API
+//
+// Usage:
+//
+//	bgstrans [transform] -ics cs -icsv a -ocs cs -ocsv b -ihs hs1 -ohs hs2 [flags] [input]
+//	bgstrans graph [-graph cs|hs] [-format dot|json] [-from system -to system]
+//
+// Input is read from a CSV/TXT or GeoJSON file, or from stdin if no file is given.
+// Exit code is 0 if all points are transformed, 1 if some points failed and 2 on usage, configuration or input errors.
+// The graph command writes the transformation graph, optionally highlighting the path between two systems.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Exit codes
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// Input formats
+const (
+	formatCSV     = "csv"
+	formatTXT     = "txt"
+	formatGeoJSON = "geojson"
+)
+
+// Main func
+func main() {
+
+	// Cancel on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	// Run command
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// Run command with arguments
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	// Run graph command
+	if len(args) > 0 && args[0] == "graph" {
+		return runGraph(args[1:], stdout, stderr)
+	}
+
+	// Run transform command, which is the default
+	if len(args) > 0 && args[0] == "transform" {
+		args = args[1:]
+	}
+
+	return runTransform(ctx, args, stdin, stdout, stderr)
+}
+
+// Write transformation graph
+func runGraph(args []string, stdout, stderr io.Writer) int {
+
+	// Define flags
+	fs := flag.NewFlagSet("bgstrans graph", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "config.yaml", "config file")
+	kind := fs.String("graph", transformations.GraphCS, "graph: cs or hs")
+	format := fs.String("format", "dot", "output format: dot or json")
+	from := fs.String("from", "", "start system of the highlighted path")
+	to := fs.String("to", "", "end system of the highlighted path")
+
+	// Parse flags
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	// Setup transformations
+	if err := setup(*configPath, stderr); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// Export graph
+	g, err := transformations.ExportGraph(*kind, *from, *to)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// Write graph
+	switch *format {
+	case "dot":
+		_, err = io.WriteString(stdout, g.DOT())
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(g)
+	default:
+		fmt.Fprintf(stderr, "unsupported format '%s'\n", *format)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	return exitOK
+}
+
+// Load and validate config, and setup transformations
+func setup(path string, stderr io.Writer) error {
+
+	// Load config
+	app, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	// Validate config
+	report := transformations.Validate(&app)
+	for _, issue := range report.Warnings {
+		fmt.Fprintf(stderr, "configuration warning: %s\n", issue)
+	}
+	if err := report.Err(); err != nil {
+		return err
+	}
+
+	// Setup transformations
+	return transformations.Setup(&app)
+}
+
+// Transform input file
+func runTransform(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+
+	// Define flags
+	fs := flag.NewFlagSet("bgstrans transform", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "config.yaml", "config file")
+	output := fs.String("o", "", "output file, defaults to stdout")
+	format := fs.String("format", "", "input format: csv, txt or geojson, defaults to the input file extension")
+	blend := fs.Bool("blend", false, "blend zones for points near zone borders")
+	workers := fs.Int("workers", 0, "number of concurrent workers")
+	quiet := fs.Bool("q", false, "don't print the summary")
+
+	// Define systems and file options, with the names of the API values
+	values := map[string]*string{}
+	for _, v := range []struct{ name, usage string }{
+		{"ics", "input coordinate system"},
+		{"icsv", "input coordinate system variant"},
+		{"ocs", "output coordinate system"},
+		{"ocsv", "output coordinate system variant"},
+		{"ihs", "input height system"},
+		{"ohs", "output height system"},
+		{"delimiter", "delimiter: comma, semicolon, tab or whitespace"},
+		{"decimal", "decimal separator: . or ,"},
+		{"name", "name column"},
+		{"x", "x column"},
+		{"y", "y column"},
+		{"h", "height column"},
+		{"code", "code column"},
+		{"z", "third coordinate column of geographic and geocentric systems"},
+		{"densify", "max GeoJSON segment length before transformation"},
+		{"simplify", "GeoJSON simplification tolerance after transformation"},
+	} {
+		values[v.name] = fs.String(v.name, "", v.usage)
+	}
+	header := fs.Bool("header", false, "skip the first row as a header")
+
+	// Parse flags
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "too many input files")
+		return exitUsage
+	}
+
+	// Get named value
+	get := func(key string) string {
+		if key == "header" {
+			return fmt.Sprint(*header)
+		}
+		if v, ok := values[key]; ok {
+			return *v
+		}
+		return ""
+	}
+
+	// Check systems
+	for _, key := range []string{"ics", "ocs", "ihs", "ohs"} {
+		if get(key) == "" {
+			fmt.Fprintf(stderr, "missing -%s flag\n", key)
+			return exitUsage
+		}
+	}
+
+	// Get input
+	input := fs.Arg(0)
+	in := stdin
+	if input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Get input format
+	if *format == "" {
+		*format = formatFromPath(input)
+	}
+
+	// Setup transformations
+	if err := setup(*configPath, stderr); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// Get transformer
+	inputCS := fmt.Sprintf("%s-%s", get("ics"), get("icsv"))
+	outputCS := fmt.Sprintf("%s-%s", get("ocs"), get("ocsv"))
+	t, err := transformations.GetTransformer(inputCS, outputCS, get("ihs"), get("ohs"))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	t.SetOptions(transformations.Options{Blend: *blend, Workers: *workers})
+
+	// Get output
+	// Output files are written to a temporary file, that replaces the output file only on success
+	out := stdout
+	var tmp *os.File
+	if *output != "" {
+		tmp, err = createTemp(*output)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+
+	// Transform input
+	var summary formats.Summary
+	switch *format {
+	case formatCSV, formatTXT:
+
+		// Get file options, text files are whitespace delimited by default
+		opts, optsErr := formats.CSVOptionsFromValues(get)
+		if optsErr != nil {
+			fmt.Fprintln(stderr, optsErr)
+			return exitUsage
+		}
+		if *format == formatTXT && get("delimiter") == "" {
+			opts.Delimiter = formats.DelimiterWhitespace
+		}
+
+		// Transform rows
+		summary, err = formats.StreamCSV(ctx, t, in, out, opts, formats.DefaultChunkSize, nil)
+
+	case formatGeoJSON:
+
+		// Get geometry options
+		opts, optsErr := formats.GeoJSONOptionsFromValues(get)
+		if optsErr != nil {
+			fmt.Fprintln(stderr, optsErr)
+			return exitUsage
+		}
+
+		// Transform features
+		summary, err = formats.TransformGeoJSON(ctx, t, in, out, opts)
+
+	default:
+		fmt.Fprintf(stderr, "unsupported format '%s'\n", *format)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// Replace output file
+	if tmp != nil {
+		if err := tmp.Close(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		if err := os.Rename(tmp.Name(), *output); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+
+	// Print summary
+	if !*quiet {
+		printSummary(stderr, *format, summary)
+	}
+
+	// Report failed points
+	if summary.Failed > 0 {
+		return exitFailed
+	}
+
+	return exitOK
+}
+
+// Create temporary file next to the output file, so it can be renamed over it
+func createTemp(output string) (*os.File, error) {
+
+	// Create file
+	f, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the permissions of created files, instead of private ones
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Get input format from file extension, defaulting to csv
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		return formatGeoJSON
+	case ".txt":
+		return formatTXT
+	}
+	return formatCSV
+}
+
+// Print transformation summary
+func printSummary(w io.Writer, format string, s formats.Summary) {
+
+	// Get item name
+	items := "points"
+	if format == formatGeoJSON {
+		items = "features"
+	}
+
+	// Print totals
+	fmt.Fprintf(w, "%d %s transformed, %d failed\n", s.Total-s.Failed, items, s.Failed)
+
+	// Print errors by code
+	codes := make([]string, 0, len(s.Errors))
+	for code := range s.Errors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "  %s: %d\n", code, s.Errors[code])
+	}
+
+	// Print blend discrepancy
+	if s.MaxDiscrepancy > 0 {
+		fmt.Fprintf(w, "max zone discrepancy: %.3f\n", s.MaxDiscrepancy)
+	}
+}
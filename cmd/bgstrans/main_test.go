@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Test config with a shift between cs-a and cs-b and a plane between hs1 and hs2
+const testConfig = `
+validCSs: [cs-a, cs-b]
+validHSs: [hs1, hs2]
+csGraph:
+  cs-a:
+    cs-b:
+      - {Type: affine, A00: 1000, A10: 1, B00: 2000, B01: 1, Invertible: true}
+hsGraph:
+  hs1:
+    hs2: {Type: plane, Name: p12, Direction: 1}
+  hs2:
+    hs1: {Type: plane, Name: p12, Direction: -1}
+hTransformations:
+  planeTransformations:
+    p12: {A: 1.5}
+`
+
+// Write test config to a temporary directory
+func writeTestConfig(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, configPath
+}
+
+// Run command with input, returning exit code, output and errors
+func runCommand(input string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(input), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// Test command exit codes and output
+func TestRun(t *testing.T) {
+
+	// Write config
+	dir, configPath := writeTestConfig(t)
+	systems := []string{"-config", configPath, "-ics", "cs", "-icsv", "b", "-ocs", "cs", "-ocsv", "a", "-ihs", "hs1", "-ohs", "hs2"}
+
+	// Transform all points
+	code, out, _ := runCommand("p1,1050,2050,10\n", append([]string{"transform"}, systems...)...)
+	if code != exitOK || out != "p1,50.000,50.000,11.500\n" {
+		t.Errorf("expected exit code %d and transformed point, received %d '%s'", exitOK, code, out)
+	}
+
+	// Report failed points
+	code, _, summary := runCommand("p1 1050 2050 10\np2 north 0 10\n", append(systems, "-format", "txt")...)
+	if code != exitFailed || !strings.Contains(summary, "1 points transformed, 1 failed") {
+		t.Errorf("expected exit code %d and summary, received %d '%s'", exitFailed, code, summary)
+	}
+
+	// Reject missing systems and unknown config
+	if code, _, _ := runCommand("", "-ics", "cs"); code != exitUsage {
+		t.Errorf("expected exit code %d, received %d", exitUsage, code)
+	}
+	if code, _, _ := runCommand("", append(systems, "-config", filepath.Join(dir, "missing.yaml"))...); code != exitUsage {
+		t.Errorf("expected exit code %d, received %d", exitUsage, code)
+	}
+}
+
+// Test GeoJSON files and output file replacement
+func TestRunGeoJSON(t *testing.T) {
+
+	// Write config and input
+	dir, configPath := writeTestConfig(t)
+	systems := []string{"-config", configPath, "-ics", "cs", "-icsv", "b", "-ocs", "cs", "-ocsv", "a", "-ihs", "hs1", "-ohs", "hs2"}
+	input := filepath.Join(dir, "in.geojson")
+	features := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"name":"a"},"geometry":{"type":"Point","coordinates":[1050,2050,10]}},
+		{"type":"Feature","properties":{},"geometry":{"type":"LineString","coordinates":[[1050,2050],[1060,2060]]}}
+	]}`
+	if err := os.WriteFile(input, []byte(features), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Transform file, detecting the format from the extension
+	output := filepath.Join(dir, "out.geojson")
+	code, _, summary := runCommand("", append(systems, "-o", output, input)...)
+	if code != exitOK || !strings.Contains(summary, "2 features transformed, 0 failed") {
+		t.Fatalf("expected exit code %d and summary, received %d '%s'", exitOK, code, summary)
+	}
+
+	// Check output
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 2 || string(fc.Features[0].Geometry.Coordinates) != "[50,50,11.5]" || string(fc.Features[1].Geometry.Coordinates) != "[[50,50],[60,60]]" {
+		t.Errorf("unexpected output %s", data)
+	}
+
+	// Check stdin with a format flag
+	code, out, _ := runCommand(features, append(systems, "-format", "geojson", "-q")...)
+	if code != exitOK || !strings.Contains(out, "[50,50,11.5]") {
+		t.Errorf("expected exit code %d and transformed features, received %d '%s'", exitOK, code, out)
+	}
+
+	// Invalid input keeps the previous output file
+	if code, _, _ := runCommand("{", append(systems, "-format", "geojson", "-o", output)...); code != exitUsage {
+		t.Errorf("expected exit code %d, received %d", exitUsage, code)
+	}
+	if kept, err := os.ReadFile(output); err != nil || !bytes.Equal(kept, data) {
+		t.Errorf("expected previous output to be kept, received '%s' %v", kept, err)
+	}
+
+	// Output in a missing directory
+	if code, _, _ := runCommand(features, append(systems, "-format", "geojson", "-o", filepath.Join(dir, "missing", "out.geojson"))...); code != exitUsage {
+		t.Errorf("expected exit code %d, received %d", exitUsage, code)
+	}
+
+	// Check no temporary files are left
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if expected := []string{"config.yaml", "in.geojson", "out.geojson"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected files %v, received %v", expected, names)
+	}
+}
+
+// Test graph command
+func TestRunGraph(t *testing.T) {
+
+	// Write config
+	_, configPath := writeTestConfig(t)
+
+	// Write DOT graph
+	code, out, _ := runCommand("", "graph", "-config", configPath)
+	if code != exitOK || !strings.HasPrefix(out, "digraph cs {") || !strings.Contains(out, `"cs-b" -> "cs-a" [label="affine", style=dashed];`) {
+		t.Errorf("expected exit code %d and DOT graph, received %d '%s'", exitOK, code, out)
+	}
+
+	// Write JSON graph with a highlighted path
+	code, out, _ = runCommand("", "graph", "-config", configPath, "-graph", "hs", "-format", "json", "-from", "hs2", "-to", "hs1")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, received %d", exitOK, code)
+	}
+	var g transformations.GraphExport
+	if err := json.Unmarshal([]byte(out), &g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Kind != transformations.GraphHS || !reflect.DeepEqual(g.Path, []string{"hs2", "hs1"}) || len(g.Adjacency["hs2"]) != 1 || !g.Adjacency["hs2"][0].Highlight {
+		t.Errorf("unexpected graph %+v", g)
+	}
+
+	// Reject unknown graph, format and systems
+	for _, args := range [][]string{
+		{"-graph", "vs"},
+		{"-format", "svg"},
+		{"-from", "hs1", "-to", "hs9", "-graph", "hs"},
+	} {
+		if code, _, _ := runCommand("", append([]string{"graph", "-config", configPath}, args...)...); code != exitUsage {
+			t.Errorf("%v: expected exit code %d, received %d", args, exitUsage, code)
+		}
+	}
+}
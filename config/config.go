@@ -1,6 +1,10 @@
 package config
 
-import "github.com/dimitargrozev5/bgstrans-2-api/util"
+import (
+	"time"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/geometry"
+)
 
 // Setup app
 type App struct {
@@ -11,21 +15,121 @@ type App struct {
 	ValidCSs []string `yaml:"validCSs"`
 	ValidHSs []string `yaml:"validHSs"`
 
+	// Human readable system descriptions
+	CSDescriptions map[string]SystemDescription `yaml:"csDescriptions"`
+	HSDescriptions map[string]SystemDescription `yaml:"hsDescriptions"`
+
+	// Coordinate system definitions, systems without a definition are projected
+	CSDefinitions map[string]CSDefinition `yaml:"csDefinitions"`
+
+	// Reference ellipsoids, GRS80, WGS84 and Krasovsky are predefined
+	Ellipsoids map[string]Ellipsoid `yaml:"ellipsoids"`
+
+	// Map projections
+	Projections map[string]Projection `yaml:"projections"`
+
 	// Coordinate transformations
 	CsGraph map[string]map[string][]CSTransformation `yaml:"csGraph"`
 
+	// Numerical solution of derived inverse transformations
+	InverseTolerance     float64 `yaml:"inverseTolerance"`
+	InverseMaxIterations int     `yaml:"inverseMaxIterations"`
+
+	// Distance from zone borders, within which points are on the border and belong to the zone
+	// In units of the zone source system, defaults to 1e-9
+	BorderTolerance float64 `yaml:"borderTolerance"`
+
+	// Width of the band on both sides of zone borders, where zones are blended when requested
+	// In units of the zone source system, defaults to 50
+	BlendBuffer float64 `yaml:"blendBuffer"`
+
 	// Height transformations
 	HsGraph          map[string]map[string]HSTransformation `yaml:"hsGraph"`
 	HTransformations TransformationMethods                  `yaml:"hTransformations"`
+
+	// Directory of grid model files
+	GridPath string `yaml:"gridPath"`
+
+	// NTv2 horizontal shift grids
+	ShiftGrids map[string]ShiftGrid `yaml:"shiftGrids"`
+
+	// Number of concurrent transformation workers, defaults to the number of CPUs
+	Workers int `yaml:"workers"`
+
+	// Asynchronous transformation jobs
+	Jobs Jobs `yaml:"jobs"`
+}
+
+// Asynchronous jobs settings
+type Jobs struct {
+	// Directory of job files, defaults to bgstrans-jobs in the temporary directory
+	Dir string `yaml:"Dir"`
+
+	// How long finished jobs are kept, defaults to 24h
+	Retention time.Duration `yaml:"Retention"`
+
+	// Number of concurrent jobs, defaults to 1
+	Workers int `yaml:"Workers"`
+}
+
+// System description
+type SystemDescription struct {
+	Name        string `yaml:"Name"`
+	Description string `yaml:"Description"`
+}
+
+// Coordinate system definition
+type CSDefinition struct {
+	// System kind: projected (default), geographic or geocentric
+	Kind      string `yaml:"Kind"`
+	Ellipsoid string `yaml:"Ellipsoid"`
+
+	// Projection of a projected system
+	Projection string `yaml:"Projection"`
+}
+
+// Reference ellipsoid
+type Ellipsoid struct {
+	A    float64 `yaml:"A"`
+	InvF float64 `yaml:"InvF"`
+}
+
+// Map projection
+// Angles are in degrees
+type Projection struct {
+	// Projection method: lcc or tm
+	Method string `yaml:"Method"`
+
+	// Latitude of origin and central meridian
+	Lat0 float64 `yaml:"Lat0"`
+	Lon0 float64 `yaml:"Lon0"`
+
+	// Standard parallels of two parallel lcc
+	Lat1 float64 `yaml:"Lat1"`
+	Lat2 float64 `yaml:"Lat2"`
+
+	// Scale factor at origin, defaults to 1
+	K0 float64 `yaml:"K0"`
+
+	FalseEasting  float64 `yaml:"FalseEasting"`
+	FalseNorthing float64 `yaml:"FalseNorthing"`
 }
 
 // TODO: This is definetly not the place for these types and methods
 // CS transformation type
 type CSTransformation struct {
-	Border []struct {
-		X float64 `yaml:"X"`
-		Y float64 `yaml:"Y"`
-	} `yaml:"Border"`
+	Name string `yaml:"Name"`
+
+	// Transformation method: polynomial (default), affine, conformal, helmert, ntv2
+	// or conversion between systems of different kinds
+	Type string `yaml:"Type"`
+
+	// Zone border polygon with optional holes
+	// Zones covering separate areas list further polygons as parts
+	Border Ring         `yaml:"Border"`
+	Holes  []Ring       `yaml:"Holes"`
+	Parts  []BorderPart `yaml:"Parts"`
+
 	X0 float64
 	Y0 float64
 
@@ -50,63 +154,90 @@ type CSTransformation struct {
 	B21 float64 `yaml:"B21"`
 	B12 float64 `yaml:"B12"`
 	B03 float64 `yaml:"B03"`
+
+	// Helmert and conformal parameters
+	// Translations are in meters, rotations in arc seconds and scale in ppm
+	// Affine transformations use the first order A and B coefficients
+	Tx float64 `yaml:"Tx"`
+	Ty float64 `yaml:"Ty"`
+	Tz float64 `yaml:"Tz"`
+	Rx float64 `yaml:"Rx"`
+	Ry float64 `yaml:"Ry"`
+	Rz float64 `yaml:"Rz"`
+	Ds float64 `yaml:"Ds"`
+
+	// Helmert rotation convention: position-vector (default) or coordinate-frame
+	Convention string `yaml:"Convention"`
+
+	// Shift grid of ntv2 transformations
+	Grid string `yaml:"Grid"`
+
+	// Derive the reverse hop numerically, if it is not defined
+	Invertible bool `yaml:"Invertible"`
+
+	// Stated standard deviation in meters, used to choose between routes and to estimate result uncertainty
+	// Routes avoid transformations without it, when a route with stated accuracies exists
+	// Zones of ntv2 transformations without it use the grid accuracies
+	Accuracy float64 `yaml:"Accuracy"`
+
+	// Zone is a derived inverse of a forward zone
+	Inverse bool `yaml:"-"`
+}
+
+// Get zone border as a multipolygon
+func (p *CSTransformation) Polygons() geometry.MultiPolygon {
+
+	// Skip zones without border
+	if len(p.Border) == 0 {
+		return nil
+	}
+
+	// Add border polygon and further parts
+	res := geometry.MultiPolygon{polygon(p.Border, p.Holes)}
+	for _, part := range p.Parts {
+		res = append(res, polygon(part.Border, part.Holes))
+	}
+
+	return res
 }
 
 // Check if point is in tranformation zone
-func (p *CSTransformation) InZone(x, y float64) bool {
-
-	// Track intersections
-	intersections := 0
-
-	for i := 0; i < len(p.Border); i++ {
-
-		// Get first polygon segment point
-		x1 := p.Border[i].X
-		y1 := p.Border[i].Y
-
-		// Get second polygon segment point
-		var x2, y2 float64
-
-		// If last segment
-		if i+1 == len(p.Border) {
-			x2 = p.Border[0].X
-			y2 = p.Border[0].Y
-		} else {
-			x2 = p.Border[i+1].X
-			y2 = p.Border[i+1].Y
-		}
-
-		// Calculate polygon line equation
-		var k, m, xInt, yInt float64
-
-		// If not horizontal
-		if x2-x1 != 0 {
-
-			// Calculate line parameters
-			k = (y2 - y1) / (x2 - x1)
-			m = y1 - k/x1
-
-			// Calculate intersection point
-			yInt = y
-			xInt = (y - m) / k
-		} else {
-			yInt = y
-			xInt = x
-		}
-
-		// Calculate helper distances
-		d12 := util.Dist(x1, y1, x2, y2)
-		d1i := util.Dist(x1, y1, xInt, yInt)
-		d2i := util.Dist(x2, y2, xInt, yInt)
-
-		// Check if the intersection point is on the polygon segment
-		if d1i < d12 && d2i <= d12 && xInt > x {
-			intersections++
-		}
+// Points on the border or within the tolerance of it are in the zone
+func (p *CSTransformation) InZone(x, y, tolerance float64) bool {
+	return p.Polygons().Contains(x, y, tolerance)
+}
+
+// Create polygon from border and holes
+func polygon(border Ring, holes []Ring) geometry.Polygon {
+	res := geometry.Polygon{Outer: border.Geometry()}
+	for _, h := range holes {
+		res.Holes = append(res.Holes, h.Geometry())
+	}
+	return res
+}
+
+// Border vertex
+type Point struct {
+	X float64 `yaml:"X"`
+	Y float64 `yaml:"Y"`
+}
+
+// Border ring, the closing vertex is optional
+type Ring []Point
+
+// Get ring vertices
+func (r Ring) Geometry() geometry.Ring {
+	res := make(geometry.Ring, len(r))
+	for i, p := range r {
+		res[i] = [2]float64{p.X, p.Y}
 	}
+	return res
+}
 
-	// Return result
-	return intersections%2 == 1
+// Further polygon of a multipolygon zone border
+type BorderPart struct {
+	Border Ring   `yaml:"Border"`
+	Holes  []Ring `yaml:"Holes"`
 }
 
 // HS tranformation
@@ -114,6 +245,11 @@ type HSTransformation struct {
 	Type      string  `yaml:"Type"`
 	Name      string  `yaml:"Name"`
 	Direction float64 `yaml:"Direction"`
+
+	// Stated standard deviation in meters, used to choose between routes and to estimate result uncertainty
+	// Routes avoid transformations without it, when a route with stated accuracies exists
+	// Grid transformations with an accuracy grid use it instead
+	Accuracy float64 `yaml:"Accuracy"`
 }
 
 // HS Tranformation methods
@@ -124,10 +260,40 @@ type TransformationMethods struct {
 
 // Grid transformation
 type HGridTransformation struct {
-	DB       string  `yaml:"DB"`
-	X0       float64 `yaml:"X0"`
-	Y0       float64 `yaml:"Y0"`
-	GridSize float64 `yaml:"GridSize"`
+	// Grid file, relative to the grid path
+	DB string `yaml:"DB"`
+
+	// File format: sqlite (default), binary, gtx or isg
+	Format string `yaml:"Format"`
+
+	// Coordinate system of grid nodes, defaults to bgs-cad
+	// Geographic grids have latitude as X and longitude as Y
+	CS string `yaml:"CS"`
+
+	// Grid origin and spacing, GridSizeY defaults to GridSize
+	X0        float64 `yaml:"X0"`
+	Y0        float64 `yaml:"Y0"`
+	GridSize  float64 `yaml:"GridSize"`
+	GridSizeY float64 `yaml:"GridSizeY"`
+
+	// Units of grid values, checked against file headers, defaults to meters
+	Units string `yaml:"Units"`
+
+	// Interpolation method: bilinear (default), bicubic, biquadratic or nearest
+	Interpolation string `yaml:"Interpolation"`
+
+	// Optional grid of standard deviations in meters, with the same format and layout as DB
+	AccuracyDB string `yaml:"AccuracyDB"`
+}
+
+// Horizontal shift grid
+type ShiftGrid struct {
+	// Grid file, relative to the grid path
+	File string `yaml:"File"`
+
+	// Expected source and target systems in the file header
+	SystemFrom string `yaml:"SystemFrom"`
+	SystemTo   string `yaml:"SystemTo"`
 }
 
 // Flat Plane tranformation
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load app config from a YAML file
+func Load(path string) (App, error) {
+
+	// Store result
+	var a App
+
+	// Open the YAML file
+	file, err := os.Open(path)
+	if err != nil {
+		return a, fmt.Errorf("error opening config file: %w", err)
+	}
+	defer file.Close()
+
+	// Decode config
+	if err := yaml.NewDecoder(file).Decode(&a); err != nil {
+		return a, fmt.Errorf("error decoding config file: %w", err)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Get zones of a CS hop as GeoJSON or WKT, with borders as polygons
+// Query values: from and to systems of the hop, optional display system, densify, simplify and format
+func getZones(w http.ResponseWriter, r *http.Request) {
+
+	// Get zones
+	fc, err := formats.ZoneFeatures(r.FormValue("from"), r.FormValue("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Transform to display system
+	if err := displayFeatures(r, fc.Features); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Write response
+	writeCoverage(w, r, fc)
+}
+
+// Get coverage of grid transformations as GeoJSON or WKT, with extents as rectangles
+// Query values: optional display system, densify, simplify and format
+func getGridCoverage(w http.ResponseWriter, r *http.Request) {
+
+	// Store result
+	fc := &formats.FeatureCollection{Type: "FeatureCollection", Features: []*formats.Feature{}}
+
+	// Iterate over grids
+	for _, c := range transformations.GridCoverages() {
+
+		// Get extent
+		f, err := formats.GridFeature(c)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Add feature
+		fc.Features = append(fc.Features, f)
+	}
+
+	// Transform to display system
+	if err := displayFeatures(r, fc.Features); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Write response
+	writeCoverage(w, r, fc)
+}
+
+// Transform features from their system to the display system, given in query values
+// Features keep their original system as the source system property
+// Transformed features keep their original geometry as the source geometry property
+func displayFeatures(r *http.Request, features []*formats.Feature) error {
+
+	// Record source systems and group features by them
+	bySource := map[string][]*formats.Feature{}
+	for _, f := range features {
+		source, _ := f.Properties["system"].(string)
+		f.Properties["sourceSystem"] = source
+		bySource[source] = append(bySource[source], f)
+	}
+
+	// Skip if no display system is set
+	display := r.FormValue("display")
+	if display == "" {
+		return nil
+	}
+
+	// Get geometry options
+	opts, err := formats.GeoJSONOptionsFromValues(r.FormValue)
+	if err != nil {
+		return err
+	}
+
+	// Get sources in order, for deterministic errors
+	sources := make([]string, 0, len(bySource))
+	for source := range bySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	// Transform features of every source system
+	for _, source := range sources {
+
+		// Skip features, that are already in the display system
+		if source == display {
+			continue
+		}
+
+		// Get transformer
+		t, err := transformations.GetCSTransformer(source, display)
+		if err != nil {
+			return err
+		}
+
+		// Record source geometries, before they are transformed in place
+		for _, f := range bySource[source] {
+			if f.Geometry == nil {
+				continue
+			}
+			raw, err := json.Marshal(f.Geometry)
+			if err != nil {
+				return err
+			}
+			f.Properties["sourceGeometry"] = json.RawMessage(raw)
+		}
+
+		// Transform features
+		fc := &formats.FeatureCollection{Type: "FeatureCollection", Features: bySource[source]}
+		if _, err := formats.TransformFeatures(r.Context(), t, fc, opts); err != nil {
+			return err
+		}
+
+		// Update systems of transformed features
+		for _, f := range fc.Features {
+			if _, failed := f.Properties[formats.PropertyError]; !failed {
+				f.Properties["system"] = display
+			}
+		}
+	}
+
+	return nil
+}
+
+// Write coverage features in the format, given in query values
+// GeoJSON is the default, WKT is written as CSV rows with a header
+func writeCoverage(w http.ResponseWriter, r *http.Request, fc *formats.FeatureCollection) {
+
+	// Write in format
+	switch format := r.FormValue("format"); format {
+	case "", "geojson":
+		writeGeoJSON(w, fc)
+
+	case "wkt":
+
+		// Write rows
+		var out bytes.Buffer
+		if err := formats.WriteWKT(&out, fc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Write response
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		out.WriteTo(w)
+
+	default:
+		http.Error(w, fmt.Sprintf("unsupported format '%s'", format), http.StatusBadRequest)
+	}
+}
+
+// Write GeoJSON response
+func writeGeoJSON(w http.ResponseWriter, fc *formats.FeatureCollection) {
+	w.Header().Set("Content-Type", geoJSONMediaType)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fc)
+}
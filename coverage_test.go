@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Get square ring
+func squareRing(x0, y0, size float64) config.Ring {
+	return config.Ring{{X: x0, Y: y0}, {X: x0, Y: y0 + size}, {X: x0 + size, Y: y0 + size}, {X: x0 + size, Y: y0}}
+}
+
+// Setup invertible zones from cs-a to cs-b, shifting X by 1000, 2000 and 3000, and a grid in cs-b
+func setupTestCoverage(t *testing.T) {
+
+	// Setup app state
+	hole := squareRing(40, 40, 20)
+	app := config.App{
+		ValidCSs: []string{"cs-a", "cs-b"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs-a": {"cs-b": {
+				{Name: "holed", Border: squareRing(0, 0, 100), Holes: []config.Ring{hole}, A00: 1000, A10: 1, B01: 1, Invertible: true},
+				{Name: "parts", Border: squareRing(100, 0, 100), Parts: []config.BorderPart{{Border: hole}}, A00: 2000, A10: 1, B01: 1, Invertible: true},
+				{Name: "everywhere", Type: transformations.CSTypeAffine, A00: 3000, A10: 1, B01: 1, Invertible: true},
+			}},
+		},
+	}
+	if err := transformations.Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs-b"}}
+	transformations.Repo.Grids = map[string]*grids.Grid{"geoid": grids.New(1000, 0, 50, 100, 0, 0, 2, 2)}
+}
+
+// Get coverage features from a handler
+func getFeatures(t *testing.T, handler http.HandlerFunc, query string) []*formats.Feature {
+
+	// Get response
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: unexpected response %d %s", query, w.Code, w.Body.String())
+	}
+
+	// Decode features
+	var fc formats.FeatureCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+
+	return fc.Features
+}
+
+// Get first position of a feature, nil if it has no geometry
+func firstPosition(t *testing.T, f *formats.Feature) formats.Position {
+	if f.Geometry == nil {
+		return nil
+	}
+	parts, err := f.Geometry.Parts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parts[0][0][0]
+}
+
+// Test zones and grid coverage in source and display systems
+func TestCoverageFeatures(t *testing.T) {
+
+	// Setup transformations
+	setupTestCoverage(t)
+
+	// Check zones of forward and derived hops, with and without a display system
+	// Borders of both hops are in cs-a, the hole of the first zone is transformed with the first zone
+	for _, c := range []struct {
+		query  string
+		system string
+		first  [][2]float64
+		holes  [2]float64
+	}{
+		{"from=cs-a&to=cs-b", "cs-a", [][2]float64{{0, 0}, {100, 0}}, [2]float64{40, 40}},
+		{"from=cs-a&to=cs-b&display=cs-a", "cs-a", [][2]float64{{0, 0}, {100, 0}}, [2]float64{40, 40}},
+		{"from=cs-a&to=cs-b&display=cs-b", "cs-b", [][2]float64{{1000, 0}, {1100, 0}}, [2]float64{1040, 40}},
+		{"from=cs-b&to=cs-a", "cs-a", [][2]float64{{0, 0}, {100, 0}}, [2]float64{40, 40}},
+		{"from=cs-b&to=cs-a&display=cs-b", "cs-b", [][2]float64{{1000, 0}, {1100, 0}}, [2]float64{1040, 40}},
+	} {
+
+		// Get zones
+		features := getFeatures(t, getZones, c.query)
+		if len(features) != 3 {
+			t.Fatalf("%s: expected 3 zones, received %d", c.query, len(features))
+		}
+
+		// Check bounded zones
+		for i, f := range features[:2] {
+			if p := firstPosition(t, f); p == nil || p[0] != c.first[i][0] || p[1] != c.first[i][1] {
+				t.Errorf("%s zone %d: expected first position %v, received %v", c.query, i, c.first[i], p)
+			}
+			if f.Properties["system"] != c.system || f.Properties["sourceSystem"] != "cs-a" {
+				t.Errorf("%s zone %d: unexpected properties %v", c.query, i, f.Properties)
+			}
+		}
+
+		// Check that transformed zones keep their source geometry
+		if c.system == "cs-b" {
+			raw, err := json.Marshal(features[0].Properties["sourceGeometry"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var g formats.Geometry
+			if err := json.Unmarshal(raw, &g); err != nil {
+				t.Fatal(err)
+			}
+			if p := firstPosition(t, &formats.Feature{Geometry: &g}); p == nil || p[0] != 0 || p[1] != 0 {
+				t.Errorf("%s: expected source geometry from 0 0, received %s", c.query, raw)
+			}
+		} else if _, ok := features[0].Properties["sourceGeometry"]; ok {
+			t.Errorf("%s: expected no source geometry without transformation", c.query)
+		}
+
+		// Check hole and part
+		parts, err := features[0].Geometry.Parts()
+		if err != nil || len(parts) != 1 || len(parts[0]) != 2 || parts[0][1][0][0] != c.holes[0] || parts[0][1][0][1] != c.holes[1] {
+			t.Errorf("%s: unexpected holed zone %v %v", c.query, parts, err)
+		}
+		if parts, err := features[1].Geometry.Parts(); err != nil || len(parts) != 2 {
+			t.Errorf("%s: expected two parts, received %v %v", c.query, parts, err)
+		}
+
+		// Check unbounded zone
+		if f := features[2]; f.Geometry != nil || f.Properties["bounded"] != false || f.Properties["sourceSystem"] != "cs-a" {
+			t.Errorf("%s: unexpected unbounded zone %+v", c.query, f)
+		}
+	}
+
+	// Check grid coverage, with and without a display system
+	for _, c := range []struct {
+		query  string
+		system string
+		first  [2]float64
+	}{
+		{"", "cs-b", [2]float64{1000, 0}},
+		{"display=cs-a", "cs-a", [2]float64{0, 0}},
+	} {
+		features := getFeatures(t, getGridCoverage, c.query)
+		if len(features) != 1 {
+			t.Fatalf("%s: expected 1 grid, received %d", c.query, len(features))
+		}
+		f := features[0]
+		if p := firstPosition(t, f); p == nil || p[0] != c.first[0] || p[1] != c.first[1] {
+			t.Errorf("%s: expected first position %v, received %v", c.query, c.first, p)
+		}
+		if f.Properties["system"] != c.system || f.Properties["sourceSystem"] != "cs-b" || f.Properties["name"] != "geoid" {
+			t.Errorf("%s: unexpected properties %v", c.query, f.Properties)
+		}
+	}
+}
+
+// Test coverage formats and invalid requests
+func TestCoverageFormats(t *testing.T) {
+
+	// Setup transformations
+	setupTestCoverage(t)
+
+	// Get response
+	get := func(handler http.HandlerFunc, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		return w
+	}
+
+	// Write zones as WKT in the display system
+	w := get(getZones, "from=cs-b&to=cs-a&display=cs-b&format=wkt")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 4 || lines[0] != "id,name,system,wkt" || !strings.HasPrefix(lines[1], "0,holed,cs-b,\"POLYGON ((1000 0, 1000 100,") {
+		t.Errorf("unexpected WKT rows %v", lines)
+	}
+
+	// Write grids as WKT
+	w = get(getGridCoverage, "format=wkt")
+	if expected := "id,name,system,wkt\ngeoid,geoid,cs-b,\"POLYGON ((1000 0, 1000 100, 1050 100, 1050 0, 1000 0))\"\n"; w.Body.String() != expected {
+		t.Errorf("expected %s, received %s", expected, w.Body.String())
+	}
+
+	// Reject invalid requests
+	for _, c := range []struct {
+		handler http.HandlerFunc
+		query   string
+	}{
+		{getZones, "from=cs-a&to=cs-c"},
+		{getZones, "from=cs-a&to=cs-b&display=cs-c"},
+		{getZones, "from=cs-a&to=cs-b&format=kml"},
+		{getZones, "from=cs-a&to=cs-b&display=cs-b&densify=x"},
+		{getGridCoverage, "display=cs-c"},
+	} {
+		if w := get(c.handler, c.query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, received %d", c.query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Max size of uploaded files held in memory
+const maxUploadMemory = 32 << 20
+
+// Transform uploaded CSV/TXT file
+// Accepts a multipart form with a "file" field or a raw text body
+// Systems and file options are read from form or query values
+func transformFile(w http.ResponseWriter, r *http.Request) {
+
+	// Close response body
+	defer r.Body.Close()
+
+	// Get file contents
+	var file io.Reader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+
+		// Parse form
+		if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+			http.Error(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Get file
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Missing file: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		file = f
+
+	case "text/csv", "text/plain":
+		file = r.Body
+
+	default:
+		http.Error(w, "Content-Type must be multipart/form-data, text/csv or text/plain", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Get file options
+	opts, err := csvOptionsFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get transformer
+	transformer, err := transformerFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Transform file
+	var out bytes.Buffer
+	summary, err := formats.TransformCSV(r.Context(), transformer, file, &out, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("X-Total-Points", strconv.Itoa(summary.Total))
+	w.Header().Set("X-Failed-Points", strconv.Itoa(summary.Failed))
+
+	// Set the status code
+	w.WriteHeader(http.StatusOK)
+
+	// Write to response
+	out.WriteTo(w)
+}
+
+// Get transformer from form or query values
+func transformerFromForm(r *http.Request) (transformations.Transformer, error) {
+	return transformerFromValues(r.FormValue)
+}
+
+// Get transformer from request values
+func transformerFromValues(get func(key string) string) (transformations.Transformer, error) {
+
+	// Get CS names
+	inputCS := fmt.Sprintf("%s-%s", get("ics"), get("icsv"))
+	outputCS := fmt.Sprintf("%s-%s", get("ocs"), get("ocsv"))
+
+	// Get transformer
+	t, err := transformations.GetTransformer(inputCS, outputCS, get("ihs"), get("ohs"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set options
+	t.SetOptions(transformations.Options{Blend: get("blend") == "true"})
+
+	return t, nil
+}
+
+// Get delimited text options from form or query values
+func csvOptionsFromForm(r *http.Request) (formats.CSVOptions, error) {
+	return formats.CSVOptionsFromValues(r.FormValue)
+}
@@ -0,0 +1,139 @@
+package formats
+
+import (
+	"fmt"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Create polygon geometry from a single ring
+func polygon(ring []Position) (*Geometry, error) {
+	g := &Geometry{Type: "Polygon"}
+	return g, g.SetParts([][][]Position{{ring}})
+}
+
+// Get closed ring positions
+func closedRing(r config.Ring) []Position {
+	ring := make([]Position, 0, len(r)+1)
+	for _, p := range r {
+		ring = append(ring, Position{p.X, p.Y})
+	}
+	if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+		ring = append(ring, ring[0])
+	}
+	return ring
+}
+
+// Get zone border as a polygon, or a multipolygon if the zone has parts
+func zoneGeometry(zone config.CSTransformation) (*Geometry, error) {
+
+	// Get polygon rings
+	rings := func(border config.Ring, holes []config.Ring) [][]Position {
+		res := [][]Position{closedRing(border)}
+		for _, h := range holes {
+			res = append(res, closedRing(h))
+		}
+		return res
+	}
+
+	// Get polygons
+	parts := [][][]Position{rings(zone.Border, zone.Holes)}
+	for _, part := range zone.Parts {
+		parts = append(parts, rings(part.Border, part.Holes))
+	}
+
+	// Create geometry
+	g := &Geometry{Type: "Polygon"}
+	if len(parts) > 1 {
+		g.Type = "MultiPolygon"
+	}
+	return g, g.SetParts(parts)
+}
+
+// Get zones of a CS hop as features, with borders as polygons in the system of the border
+// Borders of derived inverse zones are in the hop target system, which is the source system of their forward zone
+// Zones with holes or parts have polygons with holes or multipolygons
+// Zones without a border are valid everywhere and have a null geometry
+func ZoneFeatures(from, to string) (*FeatureCollection, error) {
+
+	// Get zones
+	zones, ok := transformations.Repo.CSGraph.Get(from, to)
+	if !ok {
+		return nil, fmt.Errorf("no transformation from %s to %s", from, to)
+	}
+
+	// Store result
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+
+	// Iterate over zones
+	for i, zone := range zones {
+
+		// Create feature
+		zoneType := zone.Type
+		if zoneType == "" {
+			zoneType = transformations.CSTypePolynomial
+		}
+		system := from
+		if zone.Inverse {
+			system = to
+		}
+		f := &Feature{
+			Type: "Feature",
+			ID:   i,
+			Properties: map[string]any{
+				"from":     from,
+				"to":       to,
+				"zone":     i,
+				"name":     zone.Name,
+				"type":     zoneType,
+				"accuracy": zone.Accuracy,
+				"derived":  zone.Inverse,
+				"bounded":  len(zone.Border) > 0,
+				"system":   system,
+			},
+		}
+
+		// Add border polygons
+		if len(zone.Border) > 0 {
+			var err error
+			if f.Geometry, err = zoneGeometry(zone); err != nil {
+				return nil, err
+			}
+		}
+
+		// Add feature
+		fc.Features = append(fc.Features, f)
+	}
+
+	return fc, nil
+}
+
+// Get grid coverage as a rectangle feature in the grid system
+func GridFeature(c transformations.GridCoverage) (*Feature, error) {
+
+	// Create extent
+	g, err := polygon([]Position{
+		{c.MinX, c.MinY},
+		{c.MinX, c.MaxY},
+		{c.MaxX, c.MaxY},
+		{c.MaxX, c.MinY},
+		{c.MinX, c.MinY},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Feature{
+		Type:     "Feature",
+		ID:       c.Name,
+		Geometry: g,
+		Properties: map[string]any{
+			"name":          c.Name,
+			"dx":            c.DX,
+			"dy":            c.DY,
+			"interpolation": c.Interpolation,
+			"system":        c.CS,
+		},
+	}, nil
+}
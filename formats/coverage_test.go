@@ -0,0 +1,116 @@
+package formats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Get square ring
+func squareRing(x0, y0, size float64) config.Ring {
+	return config.Ring{{X: x0, Y: y0}, {X: x0, Y: y0 + size}, {X: x0 + size, Y: y0 + size}, {X: x0 + size, Y: y0}}
+}
+
+// Setup invertible zones from cs-a to cs-b with a hole, a part covering the hole and no border
+func setupTestZones(t *testing.T) {
+
+	// Setup app state
+	hole := squareRing(40, 40, 20)
+	app := config.App{
+		ValidCSs: []string{"cs-a", "cs-b"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs-a": {"cs-b": {
+				{Name: "holed", Border: squareRing(0, 0, 100), Holes: []config.Ring{hole}, A00: 1000, A10: 1, B01: 1, Accuracy: 0.1, Invertible: true},
+				{Name: "parts", Border: squareRing(100, 0, 100), Parts: []config.BorderPart{{Border: hole}}, A00: 2000, A10: 1, B01: 1, Invertible: true},
+				{Name: "everywhere", Type: transformations.CSTypeAffine, A00: 3000, A10: 1, B01: 1, Invertible: true},
+			}},
+		},
+	}
+	if err := transformations.Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test zone features of forward and derived hops
+func TestZoneFeatures(t *testing.T) {
+
+	// Setup transformations
+	setupTestZones(t)
+
+	// Expected polygons
+	square := `[[0,0],[0,100],[100,100],[100,0],[0,0]]`
+	hole := `[[40,40],[40,60],[60,60],[60,40],[40,40]]`
+	right := `[[100,0],[100,100],[200,100],[200,0],[100,0]]`
+	geometries := []string{
+		`{"type":"Polygon","coordinates":[` + square + `,` + hole + `]}`,
+		`{"type":"MultiPolygon","coordinates":[[` + right + `],[` + hole + `]]}`,
+		"null",
+	}
+
+	// Check forward and derived hops, borders of both are in cs-a
+	for _, c := range []struct {
+		from, to string
+		derived  bool
+	}{
+		{"cs-a", "cs-b", false},
+		{"cs-b", "cs-a", true},
+	} {
+
+		// Get zones
+		fc, err := ZoneFeatures(c.from, c.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fc.Features) != 3 {
+			t.Fatalf("%s %s: expected 3 zones, received %d", c.from, c.to, len(fc.Features))
+		}
+
+		// Check zones
+		for i, f := range fc.Features {
+
+			// Check geometry
+			if g := geometryJSON(t, f.Geometry); g != geometries[i] {
+				t.Errorf("%s %s zone %d: expected geometry %s, received %s", c.from, c.to, i, geometries[i], g)
+			}
+
+			// Check properties
+			if f.ID != i || f.Properties["zone"] != i || f.Properties["from"] != c.from || f.Properties["to"] != c.to ||
+				f.Properties["system"] != "cs-a" || f.Properties["derived"] != c.derived || f.Properties["bounded"] != (i < 2) {
+				t.Errorf("%s %s zone %d: unexpected properties %v", c.from, c.to, i, f.Properties)
+			}
+		}
+
+		// Check zone details
+		if p := fc.Features[0].Properties; p["name"] != "holed" || p["type"] != transformations.CSTypePolynomial || p["accuracy"] != 0.1 {
+			t.Errorf("%s %s: unexpected zone properties %v", c.from, c.to, p)
+		}
+	}
+
+	// Reject missing hops
+	if _, err := ZoneFeatures("cs-a", "cs-c"); err == nil {
+		t.Error("expected error for missing hop")
+	}
+}
+
+// Test grid coverage feature
+func TestGridFeature(t *testing.T) {
+
+	// Get feature
+	f, err := GridFeature(transformations.GridCoverage{Name: "geoid", CS: "cs-b", MinX: 1000, MinY: 0, MaxX: 1050, MaxY: 100, DX: 50, DY: 100, Interpolation: "bilinear"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check extent
+	if g, expected := geometryJSON(t, f.Geometry), `{"type":"Polygon","coordinates":[[[1000,0],[1000,100],[1050,100],[1050,0],[1000,0]]]}`; g != expected {
+		t.Errorf("expected geometry %s, received %s", expected, g)
+	}
+
+	// Check properties
+	expected := map[string]any{"name": "geoid", "dx": 50.0, "dy": 100.0, "interpolation": "bilinear", "system": "cs-b"}
+	if f.ID != "geoid" || !reflect.DeepEqual(f.Properties, expected) {
+		t.Errorf("expected properties %v, received %v", expected, f.Properties)
+	}
+}
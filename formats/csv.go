@@ -0,0 +1,266 @@
+package formats
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Supported delimiters
+const (
+	DelimiterComma      = "comma"
+	DelimiterSemicolon  = "semicolon"
+	DelimiterTab        = "tab"
+	DelimiterWhitespace = "whitespace"
+)
+
+// Delimited text options
+type CSVOptions struct {
+	Delimiter    string
+	Header       bool
+	DecimalComma bool
+	Columns      Columns
+}
+
+// Get delimiter separator
+func (o CSVOptions) separator() (string, error) {
+	switch o.Delimiter {
+	case DelimiterComma, "":
+		return ",", nil
+	case DelimiterSemicolon:
+		return ";", nil
+	case DelimiterTab:
+		return "\t", nil
+	case DelimiterWhitespace:
+		return " ", nil
+	}
+	return "", fmt.Errorf("unsupported delimiter %s", o.Delimiter)
+}
+
+// Delimited text reader
+// Rows are read line by line, so empty lines are kept and output rows stay aligned with the input
+type CSVReader struct {
+	scanner *bufio.Scanner
+	sep     string
+	line    int
+}
+
+// Create delimited text reader
+func NewCSVReader(r io.Reader, o CSVOptions) (*CSVReader, error) {
+
+	// Get separator
+	sep, err := o.separator()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create scanner, allowing for long lines
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return &CSVReader{scanner: scanner, sep: sep}, nil
+}
+
+// Read next row
+// Returns io.EOF when there are no more rows
+func (r *CSVReader) Read() ([]string, error) {
+
+	// Scan next line
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.line++
+
+	// Get line without line endings
+	line := strings.TrimRight(r.scanner.Text(), "\r")
+
+	// Keep empty lines empty
+	if strings.TrimSpace(line) == "" {
+		return []string{}, nil
+	}
+
+	// Split on whitespace
+	if r.sep == " " {
+		return strings.Fields(line), nil
+	}
+
+	// Split on separator, respecting quoted fields
+	// Leading spaces are skipped before quotes, except in tab delimited text, where they would merge empty fields
+	cr := csv.NewReader(strings.NewReader(line))
+	cr.Comma = []rune(r.sep)[0]
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = r.sep != "\t"
+	fields, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line, err)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	return fields, nil
+}
+
+// Delimited text writer
+// Fields are quoted when needed, except in whitespace delimited text
+type CSVWriter struct {
+	w   *bufio.Writer
+	csv *csv.Writer
+}
+
+// Create delimited text writer
+func NewCSVWriter(w io.Writer, o CSVOptions) (*CSVWriter, error) {
+
+	// Get separator
+	sep, err := o.separator()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create whitespace writer
+	if sep == " " {
+		return &CSVWriter{w: bufio.NewWriter(w)}, nil
+	}
+
+	// Create quoting writer
+	cw := csv.NewWriter(w)
+	cw.Comma = []rune(sep)[0]
+
+	return &CSVWriter{csv: cw}, nil
+}
+
+// Write row
+func (w *CSVWriter) Write(fields []string) error {
+	if w.csv != nil {
+		return w.csv.Write(fields)
+	}
+	_, err := w.w.WriteString(strings.Join(fields, " ") + "\n")
+	return err
+}
+
+// Flush buffered rows
+func (w *CSVWriter) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.w.Flush()
+}
+
+// Parse delimited row to point
+func (o CSVOptions) ParseFields(fields []string) (*transformations.PointResult, bool) {
+	return ParseColumns(fields, o.Columns, o.DecimalComma)
+}
+
+// Build output row, keeping the input layout
+// Transformed values replace the input coordinates and errors are added as a last field
+func (o CSVOptions) FormatFields(fields []string, pt *transformations.PointResult) []string {
+
+	// Copy fields
+	res := append([]string{}, fields...)
+
+	// Keep rows without coordinates
+	if !hasColumn(fields, o.Columns.X) || !hasColumn(fields, o.Columns.Y) {
+		return res
+	}
+
+	// Update coordinates
+	if len(pt.XYErr) == 0 {
+		res[o.Columns.X] = FormatNumber(pt.X, o.DecimalComma)
+		res[o.Columns.Y] = FormatNumber(pt.Y, o.DecimalComma)
+		if pt.HasZ {
+			res[o.Columns.Z] = FormatNumber(pt.Z, o.DecimalComma)
+		}
+	}
+
+	// Update height
+	if len(pt.XYErr) == 0 && len(pt.HErr) == 0 && pt.HasH {
+		res[o.Columns.H] = FormatNumber(pt.H, o.DecimalComma)
+	}
+
+	// Add errors
+	if len(pt.XYErr) > 0 {
+		res = append(res, pt.XYErr)
+	} else if len(pt.HErr) > 0 {
+		res = append(res, pt.HErr)
+	}
+
+	return res
+}
+
+// Transform delimited text
+func TransformCSV(ctx context.Context, t transformations.Transformer, r io.Reader, w io.Writer, o CSVOptions) (Summary, error) {
+	return StreamCSV(ctx, t, r, w, o, 0, nil)
+}
+
+// Transform delimited text in chunks
+// Output is flushed to w after every chunk, followed by a call to flush
+func StreamCSV(ctx context.Context, t transformations.Transformer, r io.Reader, w io.Writer, o CSVOptions, chunkSize int, flush func() error) (Summary, error) {
+
+	// Store summary
+	var summary Summary
+
+	// Create reader
+	reader, err := NewCSVReader(r, o)
+	if err != nil {
+		return summary, err
+	}
+
+	// Create writer
+	writer, err := NewCSVWriter(w, o)
+	if err != nil {
+		return summary, err
+	}
+
+	// Parse rows
+	parse := func(i int, fields []string) (*transformations.PointResult, bool) {
+
+		// Skip header
+		if i == 0 && o.Header {
+			return nil, false
+		}
+
+		return o.ParseFields(fields)
+	}
+
+	// Write rows
+	write := func(fields []string, pt *transformations.PointResult) error {
+
+		// Write header as is
+		if pt == nil {
+			return writer.Write(fields)
+		}
+
+		// Update summary for rows with coordinates
+		if hasColumn(fields, o.Columns.X) && hasColumn(fields, o.Columns.Y) {
+			summary.Add(pt)
+		}
+
+		return writer.Write(o.FormatFields(fields, pt))
+	}
+
+	// Flush written rows
+	flushChunk := func() error {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flush != nil {
+			return flush()
+		}
+		return nil
+	}
+
+	// Transform rows
+	err = StreamRows(ctx, t, reader, chunkSize, parse, write, flushChunk)
+
+	return summary, err
+}
@@ -0,0 +1,242 @@
+package formats
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Setup a CS hop with two zones, a plane HS hop and a grid HS hop
+// Points in the first zone are shifted by 1000 in X and in the second one by 2000, heights by 1.5 to hs2 and by -40 more to hs3
+// The grid covers X from 1000 to 1050 and Y from 0 to 100 in cs-b
+func setupTestTransformations(t *testing.T) {
+
+	// Setup app state
+	app := config.App{
+		ValidCSs: []string{"cs-a", "cs-b"},
+		ValidHSs: []string{"hs1", "hs2", "hs3"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs-a": {"cs-b": {{
+				Name:   "z1",
+				Border: config.Ring{{X: 0, Y: 0}, {X: 0, Y: 100}, {X: 100, Y: 100}, {X: 100, Y: 0}},
+				A00:    1000,
+				A10:    1,
+				B01:    1,
+			}, {
+				Name:   "z2",
+				Border: config.Ring{{X: 100, Y: 0}, {X: 100, Y: 100}, {X: 200, Y: 100}, {X: 200, Y: 0}},
+				A00:    2000,
+				A10:    1,
+				B01:    1,
+			}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: transformations.HSTypePlane, Name: "p12", Direction: 1}},
+			"hs2": {"hs3": {Type: transformations.HSTypeGrid, Name: "geoid", Direction: -1}},
+		},
+		HTransformations: config.TransformationMethods{
+			Plane: map[string]config.HPlaneTransformation{"p12": {A: 1.5}},
+		},
+	}
+	if err := transformations.Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs-b"}}
+	g := grids.New(1000, 0, 50, 100, 0, 0, 2, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			g.Set(i, j, 40)
+		}
+	}
+	transformations.Repo.Grids = map[string]*grids.Grid{"geoid": g}
+}
+
+// Get transformer from cs-a and hs1 to cs-b and hs2
+func testTransformer(t *testing.T) transformations.Transformer {
+	return testTransformerTo(t, "hs2")
+}
+
+// Get transformer from cs-a and hs1 to cs-b and an output height system
+func testTransformerTo(t *testing.T, ohs string) transformations.Transformer {
+	tr, err := transformations.GetTransformer("cs-a", "cs-b", "hs1", ohs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+// Test delimited text transformation
+func TestTransformCSV(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Check files
+	for _, c := range []struct {
+		name     string
+		opts     CSVOptions
+		input    string
+		expected string
+		total    int
+		failed   int
+	}{
+		{
+			name:     "comma with header and quoted name",
+			opts:     CSVOptions{Header: true, Columns: DefaultColumns},
+			input:    "name,x,y,h\n\"a, b\",10,20,100\n\n",
+			expected: "name,x,y,h\n\"a, b\",1010.000,20.000,101.500\n\n",
+			total:    1,
+		},
+		{
+			name:     "header without header option",
+			opts:     CSVOptions{Columns: DefaultColumns},
+			input:    "name,x,y,h\np1,10,20\n",
+			expected: "name,x,y,h,Error parsing 'x' as number\np1,1010.000,20.000\n",
+			total:    2,
+			failed:   1,
+		},
+		{
+			name:     "semicolon with decimal comma",
+			opts:     CSVOptions{Delimiter: DelimiterSemicolon, DecimalComma: true, Columns: DefaultColumns},
+			input:    "p1;10,5;20;100\r\n",
+			expected: "p1;1010,500;20,000;101,500\n",
+			total:    1,
+		},
+		{
+			name:     "tab without height",
+			opts:     CSVOptions{Delimiter: DelimiterTab, Columns: DefaultColumns},
+			input:    "p1\t10\t20\n p2\t10\t20\t\tcode\n",
+			expected: "p1\t1010.000\t20.000\np2\t1010.000\t20.000\t\tcode\n",
+			total:    2,
+		},
+		{
+			name:     "whitespace with extra fields",
+			opts:     CSVOptions{Delimiter: DelimiterWhitespace, Columns: DefaultColumns},
+			input:    "  p1   10  20   100 code\n",
+			expected: "p1 1010.000 20.000 101.500 code\n",
+			total:    1,
+		},
+		{
+			name:     "column mapping",
+			opts:     CSVOptions{Columns: Columns{Name: -1, X: 2, Y: 1, H: -1, Code: 0, Z: -1}},
+			input:    "c1,20,10\ncomment\n",
+			expected: "c1,20.000,1010.000\ncomment\n",
+			total:    1,
+		},
+		{
+			name:     "coordinate errors",
+			opts:     CSVOptions{Columns: DefaultColumns},
+			input:    "p1,500,20,100\np2,10,y\n",
+			expected: "p1,500,20,100,point out of transformation bounds\np2,10,y,Error parsing 'y' as number\n",
+			total:    2,
+			failed:   2,
+		},
+		{
+			name:     "height error",
+			opts:     CSVOptions{Columns: DefaultColumns},
+			input:    "p1,10,20,abc\n",
+			expected: "p1,1010.000,20.000,abc,Error parsing 'abc' as number\n",
+			total:    1,
+			failed:   1,
+		},
+	} {
+		var out bytes.Buffer
+		summary, err := TransformCSV(context.Background(), testTransformer(t), strings.NewReader(c.input), &out, c.opts)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if out.String() != c.expected {
+			t.Errorf("%s: expected\n%q\nreceived\n%q", c.name, c.expected, out.String())
+		}
+		if summary.Total != c.total || summary.Failed != c.failed {
+			t.Errorf("%s: expected %d points with %d failures, received %+v", c.name, c.total, c.failed, summary)
+		}
+	}
+}
+
+// Test reading rows
+func TestCSVReader(t *testing.T) {
+
+	// Read rows
+	r, err := NewCSVReader(strings.NewReader("\"p;1\"; 10 ;20\n\n \"x\"\"y\";z\n"), CSVOptions{Delimiter: DelimiterSemicolon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+
+	// Check rows
+	expected := [][]string{{"p;1", "10", "20"}, {}, {"x\"y", "z"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %q, received %q", expected, rows)
+	}
+
+	// Reject unknown delimiter
+	if _, err := NewCSVReader(strings.NewReader(""), CSVOptions{Delimiter: "pipe"}); err == nil {
+		t.Error("expected error for unknown delimiter")
+	}
+}
+
+// Test output row layout
+func TestFormatFields(t *testing.T) {
+
+	// Check layouts
+	opts := CSVOptions{Columns: Columns{Name: 0, X: 1, Y: 2, H: 3, Code: 4, Z: 5}}
+	for _, c := range []struct {
+		fields   []string
+		pt       transformations.PointResult
+		expected []string
+	}{
+		{[]string{"p1", "1", "2", "3", "c"}, transformations.PointResult{X: 10, Y: 20, H: 30, HasH: true}, []string{"p1", "10.000", "20.000", "30.000", "c"}},
+		{[]string{"p1", "1", "2", "", "c"}, transformations.PointResult{X: 10, Y: 20}, []string{"p1", "10.000", "20.000", "", "c"}},
+		{[]string{"p1", "1", "2", "3"}, transformations.PointResult{XYErr: "xy"}, []string{"p1", "1", "2", "3", "xy"}},
+		{[]string{"p1", "1", "2", "3"}, transformations.PointResult{X: 10, Y: 20, H: 3, HasH: true, HErr: "h"}, []string{"p1", "10.000", "20.000", "3", "h"}},
+		{[]string{"p1", "1", "2", "3", "c", "4"}, transformations.PointResult{X: 10, Y: 20, Z: 40, HasZ: true}, []string{"p1", "10.000", "20.000", "3", "c", "40.000"}},
+		{[]string{"comment"}, transformations.PointResult{}, []string{"comment"}},
+	} {
+		if res := opts.FormatFields(c.fields, &c.pt); !reflect.DeepEqual(res, c.expected) {
+			t.Errorf("expected %q, received %q", c.expected, res)
+		}
+	}
+}
+
+// Test delimited text options from named values
+func TestCSVOptionsFromValues(t *testing.T) {
+
+	// Check column values
+	for _, c := range []struct {
+		values map[string]string
+		valid  bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{"x": "2", "y": "1"}, true},
+		{map[string]string{"x": "3", "y": "4", "h": "5", "code": "1"}, true},
+		{map[string]string{"x": "-1"}, false},
+		{map[string]string{"code": "-2"}, false},
+		{map[string]string{"x": "1", "y": "1"}, false},
+		{map[string]string{"y": "3"}, false},
+		{map[string]string{"z": "4"}, true},
+		{map[string]string{"z": "3"}, false},
+		{map[string]string{"x": "a"}, false},
+	} {
+		get := func(key string) string { return c.values[key] }
+		if _, err := CSVOptionsFromValues(get); (err == nil) != c.valid {
+			t.Errorf("%v: expected valid %t, received %v", c.values, c.valid, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// Read collection with its foreign members
+func (fc *FeatureCollection) UnmarshalJSON(data []byte) error {
+
+	// Decode known members
+	type plain FeatureCollection
+	if err := json.Unmarshal(data, (*plain)(fc)); err != nil {
+		return err
+	}
+
+	// Decode foreign members
+	members, err := foreignMembers(data, "type", "features")
+	fc.Members = members
+
+	return err
+}
+
+// Write collection with its foreign members
+func (fc FeatureCollection) MarshalJSON() ([]byte, error) {
+
+	// Encode known members
+	type plain FeatureCollection
+	data, err := json.Marshal(plain(fc))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMembers(data, fc.Members)
+}
+
+// Read feature with its foreign members
+func (f *Feature) UnmarshalJSON(data []byte) error {
+
+	// Decode known members
+	type plain Feature
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+
+	// Decode foreign members
+	members, err := foreignMembers(data, "type", "id", "geometry", "properties")
+	f.Members = members
+
+	return err
+}
+
+// Write feature with its foreign members
+func (f Feature) MarshalJSON() ([]byte, error) {
+
+	// Encode known members
+	type plain Feature
+	data, err := json.Marshal(plain(f))
+	if err != nil {
+		return nil, err
+	}
+
+	return withMembers(data, f.Members)
+}
+
+// Get members of a JSON object, except for the known ones and crs
+func foreignMembers(data []byte, known ...string) (map[string]json.RawMessage, error) {
+
+	// Decode members
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	// Remove known members and crs
+	for _, key := range known {
+		delete(members, key)
+	}
+	delete(members, "crs")
+
+	// Keep nil for objects without foreign members
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return members, nil
+}
+
+// Add members to an encoded JSON object, in name order
+func withMembers(data []byte, members map[string]json.RawMessage) ([]byte, error) {
+
+	// Keep objects without members
+	if len(members) == 0 {
+		return data, nil
+	}
+
+	// Get sorted keys
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Reopen object
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(data, []byte("}")))
+
+	// Write members
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(members[key])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// Recompute bounding boxes of features and the collection, that have one
+// Boxes of features without geometry are removed
+func updateBBoxes(fc *FeatureCollection) {
+
+	// Track collection bounds
+	var all bounds
+
+	// Iterate features
+	for _, f := range fc.Features {
+
+		// Get feature bounds
+		var b bounds
+		if f.Geometry != nil {
+			b.addGeometry(f.Geometry)
+			all.addBounds(b)
+		}
+
+		// Update feature box
+		if box, ok := f.Members["bbox"]; ok {
+			if b.empty() {
+				delete(f.Members, "bbox")
+			} else {
+				f.Members["bbox"] = b.bbox(box)
+			}
+		}
+	}
+
+	// Update collection box
+	if box, ok := fc.Members["bbox"]; ok {
+		if all.empty() {
+			delete(fc.Members, "bbox")
+		} else {
+			fc.Members["bbox"] = all.bbox(box)
+		}
+	}
+}
+
+// Geometry bounds
+// Heights are tracked only for positions, that have one
+type bounds struct {
+	min  [3]float64
+	max  [3]float64
+	n    int
+	hasZ bool
+}
+
+// Check if no positions are added
+func (b *bounds) empty() bool {
+	return b.n == 0
+}
+
+// Add all positions of a geometry
+func (b *bounds) addGeometry(g *Geometry) {
+	for _, s := range g.Simple() {
+
+		// Skip geometries, that can't be decoded
+		parts, err := s.Parts()
+		if err != nil {
+			continue
+		}
+
+		// Add positions
+		for _, part := range parts {
+			for _, seq := range part {
+				for _, pos := range seq {
+					b.add(pos)
+				}
+			}
+		}
+	}
+}
+
+// Add position
+func (b *bounds) add(p Position) {
+
+	// Skip invalid positions
+	if len(p) < 2 {
+		return
+	}
+
+	// Start from the first position
+	if b.n == 0 {
+		b.min = [3]float64{p[0], p[1], math.Inf(1)}
+		b.max = [3]float64{p[0], p[1], math.Inf(-1)}
+	}
+	b.n++
+
+	// Extend bounds
+	for i := 0; i < len(p) && i < 3; i++ {
+		b.min[i] = math.Min(b.min[i], p[i])
+		b.max[i] = math.Max(b.max[i], p[i])
+	}
+	if len(p) > 2 {
+		b.hasZ = true
+	}
+}
+
+// Add other bounds
+func (b *bounds) addBounds(o bounds) {
+
+	// Skip empty bounds
+	if o.empty() {
+		return
+	}
+
+	// Copy to empty bounds
+	if b.empty() {
+		*b = o
+		return
+	}
+
+	// Extend bounds
+	for i := range b.min {
+		b.min[i] = math.Min(b.min[i], o.min[i])
+		b.max[i] = math.Max(b.max[i], o.max[i])
+	}
+	b.n += o.n
+	b.hasZ = b.hasZ || o.hasZ
+}
+
+// Encode bounds as a bounding box, with heights if the input box has them and any position has a height
+func (b *bounds) bbox(input json.RawMessage) json.RawMessage {
+
+	// Get input dimensions
+	var box []float64
+	json.Unmarshal(input, &box)
+
+	// Build box
+	res := []float64{b.min[0], b.min[1], b.max[0], b.max[1]}
+	if len(box) == 6 && b.hasZ {
+		res = []float64{b.min[0], b.min[1], b.min[2], b.max[0], b.max[1], b.max[2]}
+	}
+
+	// Encode box
+	data, _ := json.Marshal(res)
+	return data
+}
@@ -0,0 +1,513 @@
+package formats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/geometry"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// GeoJSON feature collection
+// Foreign members are kept on round trip, except for crs, which names the input system
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+
+	// Foreign members, like bbox or extension keys
+	Members map[string]json.RawMessage `json:"-"`
+}
+
+// GeoJSON feature
+// Foreign members are kept on round trip, except for crs, which names the input system
+type Feature struct {
+	Type       string         `json:"type"`
+	ID         any            `json:"id,omitempty"`
+	Geometry   *Geometry      `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+
+	// Foreign members, like bbox or extension keys
+	Members map[string]json.RawMessage `json:"-"`
+}
+
+// GeoJSON geometry
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometries  []*Geometry     `json:"geometries,omitempty"`
+}
+
+// GeoJSON position
+// Positions are read in the same order as data rows: X, Y and optional H
+type Position []float64
+
+// Feature error properties
+const (
+	PropertyError        = "transformError"
+	PropertyErrorCode    = "transformErrorCode"
+	PropertyZoneStraddle = "transformZoneStraddle"
+
+	// Number of vertices, that keep their input height after a height error
+	PropertyInputHeights = "transformInputHeights"
+)
+
+// Error code of features with missing or malformed coordinates
+const ErrCodeInvalidGeometry = "invalid_geometry"
+
+// GeoJSON transformation options
+type GeoJSONOptions struct {
+	// Max segment length before transformation, in input units
+	Densify float64
+
+	// Douglas-Peucker tolerance after transformation, in output units
+	Simplify float64
+}
+
+// Zones used for a single CS hop, when a geometry spans multiple zones
+type ZoneStraddle struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Zones []int  `json:"zones"`
+}
+
+// Check if geometry type is made of lines or rings
+func isLinear(geometryType string) bool {
+	switch geometryType {
+	case "LineString", "MultiLineString", "Polygon", "MultiPolygon":
+		return true
+	}
+	return false
+}
+
+// Decode geometry coordinates to parts of position sequences
+// Polygons are parts with rings, lines and multi points are parts with a single sequence
+func (g *Geometry) Parts() ([][][]Position, error) {
+	switch g.Type {
+	case "Point":
+		var c Position
+		err := json.Unmarshal(g.Coordinates, &c)
+		return [][][]Position{{{c}}}, err
+	case "MultiPoint", "LineString":
+		var c []Position
+		err := json.Unmarshal(g.Coordinates, &c)
+		return [][][]Position{{c}}, err
+	case "MultiLineString":
+		var c [][]Position
+		err := json.Unmarshal(g.Coordinates, &c)
+		res := make([][][]Position, len(c))
+		for i, line := range c {
+			res[i] = [][]Position{line}
+		}
+		return res, err
+	case "Polygon":
+		var c [][]Position
+		err := json.Unmarshal(g.Coordinates, &c)
+		return [][][]Position{c}, err
+	case "MultiPolygon":
+		var c [][][]Position
+		err := json.Unmarshal(g.Coordinates, &c)
+		return c, err
+	}
+	return nil, fmt.Errorf("unsupported geometry type %s", g.Type)
+}
+
+// Encode parts back to geometry coordinates
+func (g *Geometry) SetParts(parts [][][]Position) error {
+
+	// Build coordinates according to geometry type
+	var c any
+	switch g.Type {
+	case "Point":
+		c = parts[0][0][0]
+	case "MultiPoint", "LineString":
+		c = parts[0][0]
+	case "MultiLineString":
+		lines := make([][]Position, len(parts))
+		for i, part := range parts {
+			lines[i] = part[0]
+		}
+		c = lines
+	case "Polygon":
+		c = parts[0]
+	case "MultiPolygon":
+		c = parts
+	default:
+		return fmt.Errorf("unsupported geometry type %s", g.Type)
+	}
+
+	// Encode coordinates
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	g.Coordinates = raw
+
+	return nil
+}
+
+// Get all simple geometries, expanding geometry collections
+func (g *Geometry) Simple() []*Geometry {
+
+	// Return simple geometry
+	if g.Type != "GeometryCollection" {
+		return []*Geometry{g}
+	}
+
+	// Expand collection
+	var res []*Geometry
+	for _, child := range g.Geometries {
+		res = append(res, child.Simple()...)
+	}
+
+	return res
+}
+
+// Read GeoJSON feature collection
+func ReadGeoJSON(r io.Reader) (*FeatureCollection, error) {
+
+	// Decode collection
+	var fc FeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	// Check type
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected FeatureCollection, received %s", fc.Type)
+	}
+
+	return &fc, nil
+}
+
+// Transform every vertex in a GeoJSON feature collection
+func TransformGeoJSON(ctx context.Context, t transformations.Transformer, r io.Reader, w io.Writer, o GeoJSONOptions) (Summary, error) {
+
+	// Store summary
+	var summary Summary
+
+	// Read collection
+	fc, err := ReadGeoJSON(r)
+	if err != nil {
+		return summary, err
+	}
+
+	// Transform collection
+	summary, err = TransformFeatures(ctx, t, fc, o)
+	if err != nil {
+		return summary, err
+	}
+
+	// Write collection
+	return summary, json.NewEncoder(w).Encode(fc)
+}
+
+// Feature vertices
+type featureVertices struct {
+	geometries []*Geometry
+	parts      [][][][]Position
+	points     [][][][]*transformations.PointResult
+
+	// Geometry decoding error
+	err error
+}
+
+// Decode and densify all simple geometries of a feature
+func decodeFeature(f *Feature, o GeoJSONOptions) ([]*Geometry, [][][][]Position, error) {
+
+	// Store result
+	var geometries []*Geometry
+	var res [][][][]Position
+
+	// Iterate simple geometries
+	for _, g := range f.Geometry.Simple() {
+
+		// Check coordinates
+		if len(g.Coordinates) == 0 || string(g.Coordinates) == "null" {
+			return nil, nil, fmt.Errorf("%s has no coordinates", g.Type)
+		}
+
+		// Decode parts
+		parts, err := g.Parts()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s coordinates: %w", g.Type, err)
+		}
+
+		// Check positions
+		count := 0
+		for _, part := range parts {
+			for _, seq := range part {
+				for _, pos := range seq {
+					if len(pos) < 2 {
+						return nil, nil, fmt.Errorf("%s has a position with %d values", g.Type, len(pos))
+					}
+					count++
+				}
+			}
+		}
+		if count == 0 {
+			return nil, nil, fmt.Errorf("%s has empty coordinates", g.Type)
+		}
+
+		// Densify lines and rings
+		if o.Densify > 0 && isLinear(g.Type) {
+			for _, part := range parts {
+				for si, seq := range part {
+					part[si] = geometry.Densify(seq, o.Densify)
+				}
+			}
+		}
+
+		// Add geometry
+		geometries = append(geometries, g)
+		res = append(res, parts)
+	}
+
+	return geometries, res, nil
+}
+
+// Transform every vertex of features in place
+// Features with invalid geometries or coordinate errors get a null geometry and error properties
+// Features with height errors keep the input heights of failed vertices and report their number
+// Features spanning multiple transformation zones get a zone straddle property
+func TransformFeatures(ctx context.Context, t transformations.Transformer, fc *FeatureCollection, o GeoJSONOptions) (Summary, error) {
+
+	// Store summary
+	var summary Summary
+
+	// Store vertices by feature
+	vertices := make([]featureVertices, len(fc.Features))
+
+	// Track point id
+	id := 0
+
+	// Iterate features
+	for i, f := range fc.Features {
+
+		// Skip features without geometry
+		if f.Geometry == nil {
+			continue
+		}
+
+		// Decode geometries, reporting errors per feature
+		geometries, parts, err := decodeFeature(f, o)
+		if err != nil {
+			vertices[i].err = err
+			continue
+		}
+
+		// Iterate simple geometries
+		for gi, g := range geometries {
+
+			// Create points
+			points := make([][][]*transformations.PointResult, len(parts[gi]))
+			for pi, part := range parts[gi] {
+				points[pi] = make([][]*transformations.PointResult, len(part))
+				for si, seq := range part {
+					points[pi][si] = make([]*transformations.PointResult, len(seq))
+					for vi, pos := range seq {
+
+						// Create point
+						pt := &transformations.PointResult{X: pos[0], Y: pos[1]}
+						if len(pos) > 2 {
+							pt.H = pos[2]
+							pt.HasH = true
+						}
+
+						// Add point for tranformation
+						t.Add(id, pt)
+						id++
+
+						// Store point
+						points[pi][si][vi] = pt
+					}
+				}
+			}
+
+			// Store geometry vertices
+			vertices[i].geometries = append(vertices[i].geometries, g)
+			vertices[i].parts = append(vertices[i].parts, parts[gi])
+			vertices[i].points = append(vertices[i].points, points)
+		}
+	}
+
+	// Transform data
+	if _, err := t.TransformBatchContext(ctx); err != nil {
+		return summary, err
+	}
+
+	// Iterate features
+	for i, f := range fc.Features {
+
+		// Skip features without geometry
+		if f.Geometry == nil {
+			continue
+		}
+
+		// Report invalid geometry
+		if err := vertices[i].err; err != nil {
+			failed := &transformations.PointResult{XYErr: err.Error(), XYErrCode: ErrCodeInvalidGeometry}
+			summary.Add(failed)
+			if f.Properties == nil {
+				f.Properties = map[string]any{}
+			}
+			f.Geometry = nil
+			f.Properties[PropertyError] = failed.XYErr
+			f.Properties[PropertyErrorCode] = failed.XYErrCode
+			continue
+		}
+
+		// Track first error and vertices keeping their input height
+		var failed *transformations.PointResult
+		xyFailed := false
+		inputHeights := 0
+
+		// Track zones used for every hop
+		zones := map[[2]string]map[int]bool{}
+
+		// Iterate geometries
+		for gi, g := range vertices[i].geometries {
+
+			// Get parts
+			parts := vertices[i].parts[gi]
+
+			// Update positions
+			for pi, part := range parts {
+				for si, seq := range part {
+					for vi, pos := range seq {
+
+						// Get point
+						pt := vertices[i].points[gi][pi][si][vi]
+
+						// Track errors
+						if len(pt.XYErr) > 0 {
+							xyFailed = true
+							if failed == nil || len(failed.XYErr) == 0 {
+								failed = pt
+							}
+							continue
+						}
+						if len(pt.HErr) > 0 {
+							inputHeights++
+							if failed == nil {
+								failed = pt
+							}
+						}
+
+						// Track zones
+						for _, z := range pt.Zones {
+							hop := [2]string{z.From, z.To}
+							if zones[hop] == nil {
+								zones[hop] = map[int]bool{}
+							}
+							zones[hop][z.Zone] = true
+						}
+
+						// Update position
+						pos[0] = pt.X
+						pos[1] = pt.Y
+						if pt.HasH && len(pt.HErr) == 0 {
+							pos[2] = pt.H
+						}
+					}
+				}
+			}
+
+			// Simplify lines and rings
+			if o.Simplify > 0 && isLinear(g.Type) {
+				for _, part := range parts {
+					for si, seq := range part {
+
+						// Simplify
+						simple := geometry.Simplify(seq, o.Simplify)
+
+						// Keep valid rings only
+						if g.Type == "Polygon" || g.Type == "MultiPolygon" {
+							if len(simple) < 4 {
+								continue
+							}
+						}
+						part[si] = simple
+					}
+				}
+			}
+
+			// Update geometry
+			if err := g.SetParts(parts); err != nil {
+				return summary, err
+			}
+		}
+
+		// Report zone straddling
+		if straddle := zoneStraddles(zones); len(straddle) > 0 {
+			if f.Properties == nil {
+				f.Properties = map[string]any{}
+			}
+			f.Properties[PropertyZoneStraddle] = straddle
+		}
+
+		// Update summary
+		if failed == nil {
+			summary.Total++
+			continue
+		}
+		summary.Add(failed)
+
+		// Add error properties
+		if f.Properties == nil {
+			f.Properties = map[string]any{}
+		}
+		if xyFailed {
+			f.Geometry = nil
+			f.Properties[PropertyError] = failed.XYErr
+			f.Properties[PropertyErrorCode] = failed.XYErrCode
+		} else {
+			f.Properties[PropertyError] = failed.HErr
+			f.Properties[PropertyErrorCode] = failed.HErrCode
+			f.Properties[PropertyInputHeights] = inputHeights
+		}
+	}
+
+	// Update bounding boxes, that are still in input coordinates
+	updateBBoxes(fc)
+
+	return summary, nil
+}
+
+// Get hops, where more than one zone is used
+func zoneStraddles(zones map[[2]string]map[int]bool) []ZoneStraddle {
+
+	// Store result
+	var res []ZoneStraddle
+
+	// Iterate hops
+	for hop, used := range zones {
+
+		// Skip hops with a single zone
+		if len(used) < 2 {
+			continue
+		}
+
+		// Get sorted zones
+		z := make([]int, 0, len(used))
+		for i := range used {
+			z = append(z, i)
+		}
+		sort.Ints(z)
+
+		// Add hop
+		res = append(res, ZoneStraddle{From: hop[0], To: hop[1], Zones: z})
+	}
+
+	// Sort for stable output
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].From != res[j].From {
+			return res[i].From < res[j].From
+		}
+		return res[i].To < res[j].To
+	})
+
+	return res
+}
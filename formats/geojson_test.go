@@ -0,0 +1,228 @@
+package formats
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Read feature collection from a string
+func readTestFeatures(t *testing.T, s string) *FeatureCollection {
+	fc, err := ReadGeoJSON(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fc
+}
+
+// Get geometry as JSON
+func geometryJSON(t *testing.T, g *Geometry) string {
+	if g == nil {
+		return "null"
+	}
+	raw, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+// Test transformation of every geometry type and per feature errors
+func TestTransformFeatures(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Transform features
+	fc := readTestFeatures(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [10, 20, 100]}, "properties": null},
+		{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[10, 20], [30, 40]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[10, 20], [30, 40]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "MultiLineString", "coordinates": [[[10, 20], [30, 40]], [[50, 60], [70, 80]]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 10], [10, 10], [0, 0]]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "MultiPolygon", "coordinates": [[[[0, 0], [0, 10], [10, 10], [0, 0]]], [[[20, 20], [20, 30], [30, 30], [20, 20]]]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "GeometryCollection", "geometries": [
+			{"type": "Point", "coordinates": [10, 20]},
+			{"type": "LineString", "coordinates": [[30, 40], [50, 60]]}
+		]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[10, 20], [500, 20]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": null}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": []}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Point"}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [10]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Circle", "coordinates": [10, 20]}, "properties": {}},
+		{"type": "Feature", "geometry": null, "properties": {"name": "empty"}}
+	]}`)
+	summary, err := TransformFeatures(context.Background(), testTransformer(t), fc, GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check geometries
+	expected := []string{
+		`{"type":"Point","coordinates":[1010,20,101.5]}`,
+		`{"type":"MultiPoint","coordinates":[[1010,20],[1030,40]]}`,
+		`{"type":"LineString","coordinates":[[1010,20],[1030,40]]}`,
+		`{"type":"MultiLineString","coordinates":[[[1010,20],[1030,40]],[[1050,60],[1070,80]]]}`,
+		`{"type":"Polygon","coordinates":[[[1000,0],[1000,10],[1010,10],[1000,0]]]}`,
+		`{"type":"MultiPolygon","coordinates":[[[[1000,0],[1000,10],[1010,10],[1000,0]]],[[[1020,20],[1020,30],[1030,30],[1020,20]]]]}`,
+		`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1010,20]},{"type":"LineString","coordinates":[[1030,40],[1050,60]]}]}`,
+		"null", "null", "null", "null", "null", "null", "null",
+	}
+	for i, f := range fc.Features {
+		if g := geometryJSON(t, f.Geometry); g != expected[i] {
+			t.Errorf("feature %d: expected %s, received %s", i, expected[i], g)
+		}
+	}
+
+	// Check error properties
+	codes := map[int]string{
+		7:  transformations.ErrCodeOutOfBounds,
+		8:  ErrCodeInvalidGeometry,
+		9:  ErrCodeInvalidGeometry,
+		10: ErrCodeInvalidGeometry,
+		11: ErrCodeInvalidGeometry,
+		12: ErrCodeInvalidGeometry,
+	}
+	for i, f := range fc.Features {
+		if code := f.Properties[PropertyErrorCode]; code != nil && code != codes[i] || code == nil && codes[i] != "" {
+			t.Errorf("feature %d: expected error code '%s', received %v", i, codes[i], f.Properties)
+		}
+	}
+	if msg := fc.Features[8].Properties[PropertyError]; msg != "Point has no coordinates" {
+		t.Errorf("unexpected error message %v", msg)
+	}
+	if fc.Features[13].Properties["name"] != "empty" || len(fc.Features[13].Properties) != 1 {
+		t.Errorf("expected feature without geometry to be kept, received %v", fc.Features[13].Properties)
+	}
+
+	// Check summary, features without geometry aren't counted
+	if summary.Total != 13 || summary.Failed != 6 || summary.Errors[ErrCodeInvalidGeometry] != 5 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+// Test that foreign members are kept and bounding boxes are recomputed
+func TestFeatureForeignMembers(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Transform features with foreign members
+	var out strings.Builder
+	_, err := TransformGeoJSON(context.Background(), testTransformer(t), strings.NewReader(`{
+		"type": "FeatureCollection", "name": "survey", "bbox": [10, 20, 50, 60],
+		"crs": {"type": "name", "properties": {"name": "cs-a"}},
+		"features": [
+			{"type": "Feature", "title": "first", "bbox": [10, 20, 30, 40], "geometry": {"type": "LineString", "coordinates": [[10, 20], [30, 40]]}, "properties": {}},
+			{"type": "Feature", "bbox": [50, 60, 50, 60], "geometry": {"type": "Point", "coordinates": [500, 60]}, "properties": {}}
+		]
+	}`), &out, GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check members
+	expected := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","geometry":{"type":"LineString","coordinates":[[1010,20],[1030,40]]},"properties":{},"bbox":[1010,20,1030,40],"title":"first"},` +
+		`{"type":"Feature","geometry":null,"properties":{"transformError":"point out of transformation bounds","transformErrorCode":"out_of_bounds"}}` +
+		`],"bbox":[1010,20,1030,40],"name":"survey"}` + "\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\nreceived\n%s", expected, out.String())
+	}
+}
+
+// Test that height errors keep input heights and report them
+func TestFeatureHeightErrors(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Transform a line leaving the grid
+	fc := readTestFeatures(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[10, 20, 100], [90, 20, 100], [80, 20, 100]]}, "properties": {}}
+	]}`)
+	summary, err := TransformFeatures(context.Background(), testTransformerTo(t, "hs3"), fc, GeoJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check geometry and properties
+	f := fc.Features[0]
+	if g := geometryJSON(t, f.Geometry); g != `{"type":"LineString","coordinates":[[1010,20,61.5],[1090,20,100],[1080,20,100]]}` {
+		t.Errorf("unexpected geometry %s", g)
+	}
+	if f.Properties[PropertyErrorCode] != transformations.ErrCodeOutOfBounds || f.Properties[PropertyInputHeights] != 2 {
+		t.Errorf("unexpected properties %v", f.Properties)
+	}
+	if summary.Total != 1 || summary.Failed != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+// Test densification, simplification and zone straddling of features
+func TestFeatureGeometryOptions(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Transform a line across both zones
+	fc := readTestFeatures(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[60, 20], [140, 20]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[10, 10], [20, 10.01], [30, 10]]}, "properties": {}}
+	]}`)
+	if _, err := TransformFeatures(context.Background(), testTransformer(t), fc, GeoJSONOptions{Densify: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check densified line, that jumps between zones
+	if g := geometryJSON(t, fc.Features[0].Geometry); g != `{"type":"LineString","coordinates":[[1060,20],[1080,20],[1100,20],[2120,20],[2140,20]]}` {
+		t.Errorf("unexpected geometry %s", g)
+	}
+
+	// Check zone straddling
+	straddle, _ := fc.Features[0].Properties[PropertyZoneStraddle].([]ZoneStraddle)
+	if len(straddle) != 1 || straddle[0].From != "cs-a" || straddle[0].To != "cs-b" || len(straddle[0].Zones) != 2 {
+		t.Errorf("unexpected zone straddle %v", fc.Features[0].Properties)
+	}
+	if _, ok := fc.Features[1].Properties[PropertyZoneStraddle]; ok {
+		t.Error("expected no zone straddle for a line in a single zone")
+	}
+
+	// Simplify a line and a small ring, that would collapse
+	fc = readTestFeatures(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[10, 10], [20, 10.01], [30, 10]]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [0, 0.01], [0.01, 0.01], [0, 0]]]}, "properties": {}}
+	]}`)
+	if _, err := TransformFeatures(context.Background(), testTransformer(t), fc, GeoJSONOptions{Simplify: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check simplified line and kept ring
+	expected := []string{
+		`{"type":"LineString","coordinates":[[1010,10],[1030,10]]}`,
+		`{"type":"Polygon","coordinates":[[[1000,0],[1000,0.01],[1000.01,0.01],[1000,0]]]}`,
+	}
+	for i, f := range fc.Features {
+		if g := geometryJSON(t, f.Geometry); g != expected[i] {
+			t.Errorf("feature %d: expected %s, received %s", i, expected[i], g)
+		}
+	}
+}
+
+// Test zone straddle order
+func TestZoneStraddles(t *testing.T) {
+	res := zoneStraddles(map[[2]string]map[int]bool{
+		{"cs-b", "cs-c"}: {1: true, 0: true},
+		{"cs-a", "cs-b"}: {2: true, 0: true, 1: true},
+		{"cs-a", "cs-a"}: {0: true},
+	})
+	expected := []ZoneStraddle{{From: "cs-a", To: "cs-b", Zones: []int{0, 1, 2}}, {From: "cs-b", To: "cs-c", Zones: []int{0, 1}}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %+v, received %+v", expected, res)
+	}
+}
@@ -0,0 +1,105 @@
+package formats
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Get delimited text options from named values, like request or command line values
+// Column indexes must not be negative, and the x, y, z and h columns must differ
+func CSVOptionsFromValues(get func(key string) string) (CSVOptions, error) {
+
+	// Set defaults
+	opts := CSVOptions{
+		Delimiter:    get("delimiter"),
+		Header:       get("header") == "true",
+		DecimalComma: get("decimal") == ",",
+		Columns:      DefaultColumns,
+	}
+
+	// Get column mapping
+	columns := map[string]*int{
+		"name": &opts.Columns.Name,
+		"x":    &opts.Columns.X,
+		"y":    &opts.Columns.Y,
+		"h":    &opts.Columns.H,
+		"code": &opts.Columns.Code,
+		"z":    &opts.Columns.Z,
+	}
+	for key, col := range columns {
+
+		// Keep default if not set
+		val := get(key)
+		if val == "" {
+			continue
+		}
+
+		// Parse column index
+		i, err := strconv.Atoi(val)
+		if err != nil || i < 0 {
+			return opts, fmt.Errorf("invalid %s column '%s'", key, val)
+		}
+		*col = i
+	}
+
+	// Check that coordinate columns don't overlap
+	used := map[int]string{}
+	for _, key := range []string{"x", "y", "z", "h"} {
+		i := *columns[key]
+		if i < 0 {
+			continue
+		}
+		if other, ok := used[i]; ok {
+			return opts, fmt.Errorf("%s and %s columns must differ, received %d for both", other, key, i)
+		}
+		used[i] = key
+	}
+
+	// Check decimal separator
+	if d := get("decimal"); d != "" && d != "." && d != "," {
+		return opts, fmt.Errorf("invalid decimal separator '%s'", d)
+	}
+
+	// Decimal comma can't be used with comma delimited files
+	if opts.DecimalComma && (opts.Delimiter == DelimiterComma || opts.Delimiter == "") {
+		return opts, fmt.Errorf("%s", "decimal comma can't be used with comma delimiter")
+	}
+
+	return opts, nil
+}
+
+// Get geometry options from named values
+func GeoJSONOptionsFromValues(get func(key string) string) (GeoJSONOptions, error) {
+
+	// Store result
+	var opts GeoJSONOptions
+	var err error
+
+	// Get options
+	if opts.Densify, err = floatFromValues(get, "densify"); err != nil {
+		return opts, err
+	}
+	if opts.Simplify, err = floatFromValues(get, "simplify"); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// Get optional non negative number from named values
+func floatFromValues(get func(key string) string, key string) (float64, error) {
+
+	// Get value
+	val := get(key)
+	if val == "" {
+		return 0, nil
+	}
+
+	// Parse value
+	res, err := strconv.ParseFloat(val, 64)
+	if err != nil || res < 0 {
+		return 0, fmt.Errorf("invalid %s value '%s'", key, val)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,236 @@
+package formats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Column mapping
+// Indices are zero based, negative values mark missing columns
+type Columns struct {
+	Name int
+	X    int
+	Y    int
+	H    int
+	Code int
+
+	// Third coordinate of 3D systems, like ellipsoidal height
+	Z int
+}
+
+// Default column mapping: N, X, Y, H
+var DefaultColumns = Columns{Name: 0, X: 1, Y: 2, H: 3, Code: -1, Z: -1}
+
+// Transformation summary
+type Summary struct {
+	Total  int `json:"total"`
+	Failed int `json:"failed"`
+
+	// Number of errors by code
+	Errors map[string]int `json:"errors,omitempty"`
+
+	// Largest distance between blended zone results
+	MaxDiscrepancy float64 `json:"maxDiscrepancy,omitempty"`
+}
+
+// Add point to summary
+func (s *Summary) Add(pt *transformations.PointResult) {
+	s.Total++
+	s.MaxDiscrepancy = max(s.MaxDiscrepancy, pt.Discrepancy)
+
+	// Skip valid points
+	if len(pt.XYErr) == 0 && len(pt.HErr) == 0 {
+		return
+	}
+	s.Failed++
+
+	// Count error codes
+	if s.Errors == nil {
+		s.Errors = map[string]int{}
+	}
+	if len(pt.XYErr) > 0 {
+		s.Errors[pt.XYErrCode]++
+	}
+	if len(pt.HErr) > 0 {
+		s.Errors[pt.HErrCode]++
+	}
+}
+
+// Parse raw data row
+// Row layout depends on the number of fields
+// 0: No data
+// 1: Comment
+// 2: X, Y
+// 3: N, X, Y
+// >= 4: N, X, Y, H, (Various string fields)
+// Returns true if the row holds a point, that should be transformed
+func ParseRow(line []string) (*transformations.PointResult, bool) {
+
+	// Store output for current line
+	var o transformations.PointResult
+	var err error
+
+	// Add empty line
+	if len(line) == 0 {
+		return &o, false
+	}
+
+	// Store comment/point name
+	if len(line) == 1 || len(line) > 2 {
+		o.Name = line[0]
+
+		// Exit if only comment
+		if len(line) == 1 {
+			return &o, false
+		}
+	}
+
+	// Get X index
+	xIndex := 0
+
+	// Update index if there is a point name
+	if len(line) > 2 {
+		xIndex = 1
+	}
+
+	// Get X
+	o.X, err = strconv.ParseFloat(line[xIndex], 64)
+	if err != nil {
+		// TODO: add better error
+		o.XYErr = fmt.Sprintf("Error parsing '%s' as number", line[xIndex])
+		o.XYErrCode = transformations.ErrCodeParseX
+		return &o, false
+	}
+
+	// Parse Y
+	o.Y, err = strconv.ParseFloat(line[xIndex+1], 64)
+	if err != nil {
+		// TODO: add better error
+		o.XYErr = fmt.Sprintf("Error parsing '%s' as number", line[xIndex+1])
+		o.XYErrCode = transformations.ErrCodeParseY
+		return &o, false
+	}
+
+	// If there is an H
+	if len(line) > 3 {
+
+		// Parse H
+		o.H, err = strconv.ParseFloat(line[3], 64)
+		if err != nil {
+			// TODO: add better error
+			o.HErr = fmt.Sprintf("Error parsing '%s' as number", line[3])
+			o.HErrCode = transformations.ErrCodeParseH
+			return &o, false
+		}
+		o.HasH = true
+	}
+
+	// Get other fields
+	if len(line) > 4 {
+		o.Var = line[4:]
+	}
+
+	return &o, true
+}
+
+// Parse row using explicit column mapping
+// Returns true if the row holds a point, that should be transformed
+func ParseColumns(fields []string, cols Columns, decimalComma bool) (*transformations.PointResult, bool) {
+
+	// Store output for current line
+	var o transformations.PointResult
+
+	// Skip rows without coordinates
+	if !hasColumn(fields, cols.X) || !hasColumn(fields, cols.Y) {
+		return &o, false
+	}
+
+	// Get name and code
+	if hasColumn(fields, cols.Name) {
+		o.Name = fields[cols.Name]
+	}
+	if hasColumn(fields, cols.Code) {
+		o.Code = fields[cols.Code]
+	}
+
+	// Parse X
+	x, ok := parseNumber(fields[cols.X], decimalComma)
+	if !ok {
+		o.XYErr = fmt.Sprintf("Error parsing '%s' as number", fields[cols.X])
+		o.XYErrCode = transformations.ErrCodeParseX
+		return &o, false
+	}
+	o.X = x
+
+	// Parse Y
+	y, ok := parseNumber(fields[cols.Y], decimalComma)
+	if !ok {
+		o.XYErr = fmt.Sprintf("Error parsing '%s' as number", fields[cols.Y])
+		o.XYErrCode = transformations.ErrCodeParseY
+		return &o, false
+	}
+	o.Y = y
+
+	// Parse Z, if present
+	if hasColumn(fields, cols.Z) && len(fields[cols.Z]) > 0 {
+		z, ok := parseNumber(fields[cols.Z], decimalComma)
+		if !ok {
+			o.XYErr = fmt.Sprintf("Error parsing '%s' as number", fields[cols.Z])
+			o.XYErrCode = transformations.ErrCodeParseZ
+			return &o, false
+		}
+		o.Z = z
+		o.HasZ = true
+	}
+
+	// Parse H, if present
+	if hasColumn(fields, cols.H) && len(fields[cols.H]) > 0 {
+		h, ok := parseNumber(fields[cols.H], decimalComma)
+		if !ok {
+			o.HErr = fmt.Sprintf("Error parsing '%s' as number", fields[cols.H])
+			o.HErrCode = transformations.ErrCodeParseH
+
+			// Transform coordinates, so the row is written in the output system
+			return &o, true
+		}
+		o.H = h
+		o.HasH = true
+	}
+
+	return &o, true
+}
+
+// Format number with three decimal places
+func FormatNumber(v float64, decimalComma bool) string {
+
+	// Format number
+	res := strconv.FormatFloat(v, 'f', 3, 64)
+
+	// Replace decimal separator
+	if decimalComma {
+		res = strings.Replace(res, ".", ",", 1)
+	}
+
+	return res
+}
+
+// Parse number with optional decimal comma
+func parseNumber(s string, decimalComma bool) (float64, bool) {
+
+	// Replace decimal separator
+	if decimalComma {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	// Parse number
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
+
+// Check if column is mapped and present in row
+func hasColumn(fields []string, i int) bool {
+	return i >= 0 && i < len(fields)
+}
@@ -0,0 +1,144 @@
+package formats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Default number of rows, transformed at once when streaming
+const DefaultChunkSize = 1000
+
+// Source of data rows
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// Newline delimited JSON reader
+// Every line is a JSON array of string fields, in the /transform row layout
+type NDJSONReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Create newline delimited JSON reader
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+
+	// Create scanner, allowing for long lines
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return &NDJSONReader{scanner: scanner}
+}
+
+// Read next row
+// Returns io.EOF when there are no more rows
+func (r *NDJSONReader) Read() ([]string, error) {
+
+	// Scan next line
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.line++
+
+	// Keep empty lines empty
+	line := strings.TrimSpace(r.scanner.Text())
+	if line == "" {
+		return []string{}, nil
+	}
+
+	// Decode fields
+	var fields []string
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return nil, fmt.Errorf("invalid row on line %d: %w", r.line, err)
+	}
+
+	return fields, nil
+}
+
+// Stream rows through a transformer in chunks
+// Rows are parsed with parse, which returns nil for rows that are copied as is, and true for rows that should be transformed
+// Every row is passed to write in input order, and flush is called after every chunk
+// Memory use depends on the chunk size only, a chunk size of 0 transforms all rows at once
+func StreamRows(
+	ctx context.Context,
+	t transformations.Transformer,
+	r RowReader,
+	chunkSize int,
+	parse func(i int, fields []string) (*transformations.PointResult, bool),
+	write func(fields []string, pt *transformations.PointResult) error,
+	flush func() error,
+) error {
+
+	// Store chunk rows and points
+	var rows [][]string
+	var points []*transformations.PointResult
+
+	// Transform and write current chunk
+	writeChunk := func() error {
+
+		// Transform chunk
+		if _, err := t.TransformBatchContext(ctx); err != nil {
+			return err
+		}
+
+		// Write rows
+		for i, fields := range rows {
+			if err := write(fields, points[i]); err != nil {
+				return err
+			}
+		}
+
+		// Start next chunk
+		t.Reset()
+		rows = rows[:0]
+		points = points[:0]
+
+		// Flush output
+		if flush != nil {
+			return flush()
+		}
+		return nil
+	}
+
+	// Read rows
+	for i := 0; ; i++ {
+
+		// Read row
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Parse row
+		pt, ok := parse(i, fields)
+		rows = append(rows, fields)
+		points = append(points, pt)
+
+		// Add point for tranformation
+		if ok {
+			t.Add(i, pt)
+		}
+
+		// Write full chunk
+		if chunkSize > 0 && len(rows) >= chunkSize {
+			if err := writeChunk(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write last chunk
+	return writeChunk()
+}
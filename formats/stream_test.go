@@ -0,0 +1,181 @@
+package formats
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Transformer, that records batch sizes and resets
+type countingTransformer struct {
+	transformations.Transformer
+	added   int
+	batches []int
+	resets  int
+}
+
+// Record added point
+func (t *countingTransformer) Add(id int, pt *transformations.PointResult) {
+	t.added++
+	t.Transformer.Add(id, pt)
+}
+
+// Record reset
+func (t *countingTransformer) Reset() {
+	t.resets++
+	t.added = 0
+	t.Transformer.Reset()
+}
+
+// Record batch size
+func (t *countingTransformer) TransformBatchContext(ctx context.Context) (map[int]*transformations.PointResult, error) {
+	t.batches = append(t.batches, t.added)
+	return t.Transformer.TransformBatchContext(ctx)
+}
+
+// Row reader over a slice of rows
+type sliceReader struct {
+	rows [][]string
+	err  error
+}
+
+// Read next row, returning the reader error after the last row
+func (r *sliceReader) Read() ([]string, error) {
+	if len(r.rows) == 0 {
+		return nil, r.err
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+// Test streaming rows in chunks
+func TestStreamRows(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Rows with a comment in the middle of the second chunk
+	rows := [][]string{
+		{"p1", "10", "20"},
+		{"p2", "20", "20"},
+		{"p3", "30", "20"},
+		{"comment"},
+		{"p4", "40", "20"},
+		{"p5", "150", "20"},
+		{"p6", "500", "20"},
+	}
+
+	// Parse rows, keeping comments as is
+	parse := func(i int, fields []string) (*transformations.PointResult, bool) {
+		return ParseRow(fields)
+	}
+
+	// Check chunk sizes
+	for _, c := range []struct {
+		chunkSize int
+		batches   []int
+	}{
+		{3, []int{3, 2, 1}},
+		{2, []int{2, 1, 2, 1}},
+		{0, []int{6}},
+		{10, []int{6}},
+	} {
+
+		// Stream rows
+		tr := &countingTransformer{Transformer: testTransformer(t)}
+		var written [][]string
+		var xs []float64
+		flushed := 0
+		write := func(fields []string, pt *transformations.PointResult) error {
+			written = append(written, fields)
+			if len(fields) > 1 {
+				xs = append(xs, pt.X)
+			}
+			return nil
+		}
+		flush := func() error {
+			flushed++
+			return nil
+		}
+		reader := &sliceReader{rows: append([][]string{}, rows...), err: io.EOF}
+		if err := StreamRows(context.Background(), tr, reader, c.chunkSize, parse, write, flush); err != nil {
+			t.Fatalf("chunk %d: %v", c.chunkSize, err)
+		}
+
+		// Check that every chunk was transformed on its own, and the transformer was reset after it
+		if !reflect.DeepEqual(tr.batches, c.batches) {
+			t.Errorf("chunk %d: expected batches %v, received %v", c.chunkSize, c.batches, tr.batches)
+		}
+		if tr.resets != len(c.batches) || flushed != len(c.batches) {
+			t.Errorf("chunk %d: expected %d resets and flushes, received %d and %d", c.chunkSize, len(c.batches), tr.resets, flushed)
+		}
+
+		// Check rows are written in input order
+		if !reflect.DeepEqual(written, rows) {
+			t.Errorf("chunk %d: expected rows %v, received %v", c.chunkSize, rows, written)
+		}
+		if expected := []float64{1010, 1020, 1030, 1040, 2150, 500}; !reflect.DeepEqual(xs, expected) {
+			t.Errorf("chunk %d: expected X %v, received %v", c.chunkSize, expected, xs)
+		}
+	}
+
+	// Check read and write errors stop the stream
+	readErr := errors.New("read failed")
+	reader := &sliceReader{rows: rows[:2], err: readErr}
+	if err := StreamRows(context.Background(), testTransformer(t), reader, 1, parse, func([]string, *transformations.PointResult) error { return nil }, nil); err != readErr {
+		t.Errorf("expected read error, received %v", err)
+	}
+	writeErr := errors.New("write failed")
+	written := 0
+	reader = &sliceReader{rows: rows, err: io.EOF}
+	err := StreamRows(context.Background(), testTransformer(t), reader, 2, parse, func([]string, *transformations.PointResult) error {
+		written++
+		if written == 3 {
+			return writeErr
+		}
+		return nil
+	}, nil)
+	if err != writeErr || written != 3 {
+		t.Errorf("expected write error after 3 rows, received %v after %d", err, written)
+	}
+
+	// Check cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reader = &sliceReader{rows: rows, err: io.EOF}
+	if err := StreamRows(ctx, testTransformer(t), reader, 2, parse, func([]string, *transformations.PointResult) error { return nil }, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled stream, received %v", err)
+	}
+}
+
+// Test NDJSON rows and parse error line numbers
+func TestNDJSONReader(t *testing.T) {
+
+	// Read rows with an empty line and an invalid line
+	r := NewNDJSONReader(strings.NewReader("[\"p1\",\"10\",\"20\"]\n\n  [\"comment\"]  \n[\"p2\",10]\n[\"p3\"]\n"))
+	for i, expected := range [][]string{{"p1", "10", "20"}, {}, {"comment"}} {
+		fields, err := r.Read()
+		if err != nil || !reflect.DeepEqual(fields, expected) {
+			t.Errorf("row %d: expected %v, received %v %v", i, expected, fields, err)
+		}
+	}
+
+	// Check error line
+	if _, err := r.Read(); err == nil || !strings.HasPrefix(err.Error(), "invalid row on line 4:") {
+		t.Errorf("expected error on line 4, received %v", err)
+	}
+
+	// Check reading continues after the invalid line
+	if fields, err := r.Read(); err != nil || !reflect.DeepEqual(fields, []string{"p3"}) {
+		t.Errorf("expected row after error, received %v %v", fields, err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("expected end of rows, received %v", err)
+	}
+}
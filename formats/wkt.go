@@ -0,0 +1,154 @@
+package formats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Get geometry as well-known text
+// Positions keep their order and all of their values, so geometries with heights are written with Z
+func (g *Geometry) WKT() (string, error) {
+
+	// Write collections from their geometries
+	if g.Type == "GeometryCollection" {
+		children := make([]string, len(g.Geometries))
+		for i, child := range g.Geometries {
+			s, err := child.WKT()
+			if err != nil {
+				return "", err
+			}
+			children[i] = s
+		}
+		if len(children) == 0 {
+			return "GEOMETRYCOLLECTION EMPTY", nil
+		}
+		return fmt.Sprintf("GEOMETRYCOLLECTION (%s)", strings.Join(children, ", ")), nil
+	}
+
+	// Get parts
+	parts, err := g.Parts()
+	if err != nil {
+		return "", err
+	}
+
+	// Get tag, with Z for positions with heights
+	tag := strings.ToUpper(g.Type)
+	if hasHeights(parts) {
+		tag += " Z"
+	}
+
+	// Write positions of parts
+	var text string
+	switch g.Type {
+	case "Point":
+		text = wktPositions(parts[0][0])
+	case "MultiPoint":
+		points := make([]string, len(parts[0][0]))
+		for i, p := range parts[0][0] {
+			points[i] = wktPositions([]Position{p})
+		}
+		text = wktList(points)
+	case "LineString":
+		text = wktPositions(parts[0][0])
+	case "MultiLineString":
+		lines := make([]string, len(parts))
+		for i, part := range parts {
+			lines[i] = wktPositions(part[0])
+		}
+		text = wktList(lines)
+	case "Polygon":
+		text = wktRings(parts[0])
+	case "MultiPolygon":
+		polygons := make([]string, len(parts))
+		for i, part := range parts {
+			polygons[i] = wktRings(part)
+		}
+		text = wktList(polygons)
+	}
+
+	// Write geometries without positions as empty
+	if text == "()" {
+		return strings.ToUpper(g.Type) + " EMPTY", nil
+	}
+
+	return tag + " " + text, nil
+}
+
+// Check if any of the positions has a height
+func hasHeights(parts [][][]Position) bool {
+	for _, part := range parts {
+		for _, seq := range part {
+			for _, p := range seq {
+				if len(p) > 2 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Write position sequence as a list of space separated values
+func wktPositions(seq []Position) string {
+	res := make([]string, len(seq))
+	for i, p := range seq {
+		values := make([]string, len(p))
+		for j, v := range p {
+			values[j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		res[i] = strings.Join(values, " ")
+	}
+	return wktList(res)
+}
+
+// Write polygon rings
+func wktRings(rings [][]Position) string {
+	res := make([]string, len(rings))
+	for i, ring := range rings {
+		res[i] = wktPositions(ring)
+	}
+	return wktList(res)
+}
+
+// Write list of WKT items in parentheses
+func wktList(items []string) string {
+	return "(" + strings.Join(items, ", ") + ")"
+}
+
+// Write features as CSV rows of id, name, system and WKT geometry
+// Features without a geometry have an empty WKT value
+func WriteWKT(w io.Writer, fc *FeatureCollection) error {
+
+	// Write header
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "system", "wkt"})
+
+	// Write features
+	for _, f := range fc.Features {
+
+		// Get geometry
+		text := ""
+		if f.Geometry != nil {
+			var err error
+			if text, err = f.Geometry.WKT(); err != nil {
+				return err
+			}
+		}
+
+		// Write row
+		id := ""
+		if f.ID != nil {
+			id = fmt.Sprint(f.ID)
+		}
+		name, _ := f.Properties["name"].(string)
+		system, _ := f.Properties["system"].(string)
+		cw.Write([]string{id, name, system, text})
+	}
+
+	// Flush rows
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,70 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// Test well-known text of every geometry type
+func TestWKT(t *testing.T) {
+
+	// Check geometries
+	for _, c := range []struct {
+		geometry string
+		expected string
+	}{
+		{`{"type":"Point","coordinates":[10,20]}`, "POINT (10 20)"},
+		{`{"type":"Point","coordinates":[10.25,20,5]}`, "POINT Z (10.25 20 5)"},
+		{`{"type":"MultiPoint","coordinates":[[10,20],[30,40]]}`, "MULTIPOINT ((10 20), (30 40))"},
+		{`{"type":"LineString","coordinates":[[10,20],[30,40]]}`, "LINESTRING (10 20, 30 40)"},
+		{`{"type":"MultiLineString","coordinates":[[[10,20],[30,40]],[[50,60],[70,80]]]}`, "MULTILINESTRING ((10 20, 30 40), (50 60, 70 80))"},
+		{`{"type":"Polygon","coordinates":[[[0,0],[0,10],[10,10],[0,0]],[[1,1],[1,2],[2,2],[1,1]]]}`, "POLYGON ((0 0, 0 10, 10 10, 0 0), (1 1, 1 2, 2 2, 1 1))"},
+		{`{"type":"MultiPolygon","coordinates":[[[[0,0],[0,10],[10,10],[0,0]]],[[[20,20],[20,30],[30,30],[20,20]]]]}`, "MULTIPOLYGON (((0 0, 0 10, 10 10, 0 0)), ((20 20, 20 30, 30 30, 20 20)))"},
+		{`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]},{"type":"LineString","coordinates":[[1,2],[3,4]]}]}`, "GEOMETRYCOLLECTION (POINT (1 2), LINESTRING (1 2, 3 4))"},
+		{`{"type":"GeometryCollection","geometries":[]}`, "GEOMETRYCOLLECTION EMPTY"},
+		{`{"type":"LineString","coordinates":[]}`, "LINESTRING EMPTY"},
+		{`{"type":"Polygon","coordinates":[]}`, "POLYGON EMPTY"},
+	} {
+
+		// Decode geometry
+		var g Geometry
+		if err := json.Unmarshal([]byte(c.geometry), &g); err != nil {
+			t.Fatal(err)
+		}
+
+		// Check text
+		if text, err := g.WKT(); err != nil || text != c.expected {
+			t.Errorf("%s: expected %s, received %s %v", c.geometry, c.expected, text, err)
+		}
+	}
+
+	// Reject unknown geometries
+	if _, err := (&Geometry{Type: "Circle"}).WKT(); err == nil {
+		t.Error("expected error for unsupported geometry type")
+	}
+}
+
+// Test writing features as WKT rows
+func TestWriteWKT(t *testing.T) {
+
+	// Write zones, one without a border
+	setupTestZones(t)
+	fc, err := ZoneFeatures("cs-a", "cs-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := WriteWKT(&out, fc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check rows
+	expected := "id,name,system,wkt\n" +
+		"0,holed,cs-a,\"POLYGON ((0 0, 0 100, 100 100, 100 0, 0 0), (40 40, 40 60, 60 60, 60 40, 40 40))\"\n" +
+		"1,parts,cs-a,\"MULTIPOLYGON (((100 0, 100 100, 200 100, 200 0, 100 0)), ((40 40, 40 60, 60 60, 60 40, 40 40)))\"\n" +
+		"2,everywhere,cs-a,\n"
+	if out.String() != expected {
+		t.Errorf("expected %s, received %s", expected, out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+)
+
+// GeoJSON media type
+const geoJSONMediaType = "application/geo+json"
+
+// Transform GeoJSON feature collection
+// Systems are read from query values
+func transformGeoJSON(w http.ResponseWriter, r *http.Request) {
+
+	// Close response body
+	defer r.Body.Close()
+
+	// Get transformer
+	transformer, err := transformerFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get geometry options
+	opts, err := formats.GeoJSONOptionsFromValues(r.FormValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Transform collection
+	var out bytes.Buffer
+	summary, err := formats.TransformGeoJSON(r.Context(), transformer, r.Body, &out, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers
+	w.Header().Set("Content-Type", geoJSONMediaType)
+	w.Header().Set("X-Total-Features", strconv.Itoa(summary.Total))
+	w.Header().Set("X-Failed-Features", strconv.Itoa(summary.Failed))
+
+	// Set the status code
+	w.WriteHeader(http.StatusOK)
+
+	// Write to response
+	out.WriteTo(w)
+}
@@ -0,0 +1,162 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/util"
+)
+
+// Densify line, so that no segment is longer than max spacing
+// Positions hold X, Y and optional further values, which are interpolated linearly
+func Densify[P ~[]float64](line []P, maxSpacing float64) []P {
+
+	// Nothing to densify
+	if maxSpacing <= 0 || len(line) < 2 {
+		return line
+	}
+
+	// Store result
+	res := []P{line[0]}
+
+	// Iterate segments
+	for i := 1; i < len(line); i++ {
+
+		// Get segment
+		a, b := line[i-1], line[i]
+
+		// Get number of sub segments
+		n := int(math.Ceil(util.Dist(a[0], a[1], b[0], b[1]) / maxSpacing))
+
+		// Add intermediate positions
+		for j := 1; j < n; j++ {
+
+			// Get ratio
+			t := float64(j) / float64(n)
+
+			// Interpolate values
+			p := make(P, min(len(a), len(b)))
+			for k := range p {
+				p[k] = a[k] + (b[k]-a[k])*t
+			}
+
+			// Add position
+			res = append(res, p)
+		}
+
+		// Add segment end
+		res = append(res, b)
+	}
+
+	return res
+}
+
+// Simplify line with the Douglas-Peucker algorithm
+// Vertices closer than the tolerance to the simplified line are removed
+func Simplify[P ~[]float64](line []P, tolerance float64) []P {
+
+	// Nothing to simplify
+	if tolerance <= 0 || len(line) < 3 {
+		return line
+	}
+
+	// Track kept vertices
+	keep := make([]bool, len(line))
+	keep[0] = true
+	keep[len(line)-1] = true
+
+	// Simplify
+	simplifySegment(line, 0, len(line)-1, tolerance, keep)
+
+	// Store result
+	var res []P
+	for i, p := range line {
+		if keep[i] {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// Simplify line between two vertices
+func simplifySegment[P ~[]float64](line []P, first, last int, tolerance float64, keep []bool) {
+
+	// Track farthest vertex
+	maxDist := 0.0
+	maxIndex := 0
+
+	// Iterate inner vertices
+	for i := first + 1; i < last; i++ {
+
+		// Get distance
+		d := SegmentDist(line[i][0], line[i][1], line[first][0], line[first][1], line[last][0], line[last][1])
+
+		// Update max
+		if d > maxDist {
+			maxDist = d
+			maxIndex = i
+		}
+	}
+
+	// Exit if all vertices are within tolerance
+	if maxDist <= tolerance {
+		return
+	}
+
+	// Keep vertex and simplify both sides
+	keep[maxIndex] = true
+	simplifySegment(line, first, maxIndex, tolerance, keep)
+	simplifySegment(line, maxIndex, last, tolerance, keep)
+}
+
+// Calculate distance from point to segment
+func SegmentDist(x, y, x1, y1, x2, y2 float64) float64 {
+
+	// Get segment length
+	dx := x2 - x1
+	dy := y2 - y1
+	l2 := dx*dx + dy*dy
+
+	// Handle degenerate segment
+	if l2 == 0 {
+		return util.Dist(x, y, x1, y1)
+	}
+
+	// Project point on segment
+	t := ((x-x1)*dx + (y-y1)*dy) / l2
+	t = math.Max(0, math.Min(1, t))
+
+	return util.Dist(x, y, x1+t*dx, y1+t*dy)
+}
+
+// Check if two segments intersect, including touching end points
+func SegmentsIntersect(x1, y1, x2, y2, x3, y3, x4, y4 float64) bool {
+
+	// Get orientations of end points
+	d1 := orientation(x3, y3, x4, y4, x1, y1)
+	d2 := orientation(x3, y3, x4, y4, x2, y2)
+	d3 := orientation(x1, y1, x2, y2, x3, y3)
+	d4 := orientation(x1, y1, x2, y2, x4, y4)
+
+	// Segments cross
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+
+	// End point lies on the other segment
+	return (d1 == 0 && onSegment(x1, y1, x3, y3, x4, y4)) ||
+		(d2 == 0 && onSegment(x2, y2, x3, y3, x4, y4)) ||
+		(d3 == 0 && onSegment(x3, y3, x1, y1, x2, y2)) ||
+		(d4 == 0 && onSegment(x4, y4, x1, y1, x2, y2))
+}
+
+// Get orientation of point relative to a line
+// Positive on the left, negative on the right and zero on the line
+func orientation(x1, y1, x2, y2, x, y float64) float64 {
+	return (x2-x1)*(y-y1) - (y2-y1)*(x-x1)
+}
+
+// Check if a collinear point is within the bounds of a segment
+func onSegment(x, y, x1, y1, x2, y2 float64) bool {
+	return math.Min(x1, x2) <= x && x <= math.Max(x1, x2) && math.Min(y1, y2) <= y && y <= math.Max(y1, y2)
+}
@@ -0,0 +1,49 @@
+package geometry
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test line densification
+func TestDensify(t *testing.T) {
+
+	// Check spacing
+	for _, c := range []struct {
+		line       [][]float64
+		maxSpacing float64
+		expected   [][]float64
+	}{
+		{[][]float64{{0, 0, 100}, {10, 0, 110}}, 3, [][]float64{{0, 0, 100}, {2.5, 0, 102.5}, {5, 0, 105}, {7.5, 0, 107.5}, {10, 0, 110}}},
+		{[][]float64{{0, 0}, {3, 0}, {3, 4}}, 3, [][]float64{{0, 0}, {3, 0}, {3, 2}, {3, 4}}},
+		{[][]float64{{0, 0, 100}, {0, 4}}, 2, [][]float64{{0, 0, 100}, {0, 2}, {0, 4}}},
+		{[][]float64{{0, 0}, {10, 0}}, 0, [][]float64{{0, 0}, {10, 0}}},
+		{[][]float64{{0, 0}}, 1, [][]float64{{0, 0}}},
+	} {
+		if res := Densify(c.line, c.maxSpacing); !reflect.DeepEqual(res, c.expected) {
+			t.Errorf("expected %v, received %v", c.expected, res)
+		}
+	}
+}
+
+// Test Douglas-Peucker simplification of lines and rings
+func TestSimplify(t *testing.T) {
+
+	// Check lines
+	for _, c := range []struct {
+		line      [][]float64
+		tolerance float64
+		expected  [][]float64
+	}{
+		{[][]float64{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0.05}, {4, 0}}, 0.2, [][]float64{{0, 0}, {4, 0}}},
+		{[][]float64{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0.05}, {4, 0}}, 0.01, [][]float64{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0.05}, {4, 0}}},
+		{[][]float64{{0, 0}, {5, 5}, {10, 0}}, 1, [][]float64{{0, 0}, {5, 5}, {10, 0}}},
+		{[][]float64{{0, 0}, {0, 5}, {0, 10}, {10, 10}, {10, 0}, {5, 0}, {0, 0}}, 0.1, [][]float64{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}}},
+		{[][]float64{{0, 0}, {1, 1}}, 10, [][]float64{{0, 0}, {1, 1}}},
+		{[][]float64{{0, 0}, {1, 1}, {2, 2}}, 0, [][]float64{{0, 0}, {1, 1}, {2, 2}}},
+	} {
+		if res := Simplify(c.line, c.tolerance); !reflect.DeepEqual(res, c.expected) {
+			t.Errorf("expected %v, received %v", c.expected, res)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package geometry
+
+import (
+	"math"
+)
+
+// Polygon ring of X, Y vertices, the closing vertex is optional
+type Ring [][2]float64
+
+// Polygon with an outer ring and optional holes
+type Polygon struct {
+	Outer Ring
+	Holes []Ring
+}
+
+// Polygons of a multipolygon, which may touch but should not overlap
+type MultiPolygon []Polygon
+
+// Location of a point relative to a polygon
+type Location int
+
+// Point locations
+const (
+	Outside Location = iota
+	Boundary
+	Inside
+)
+
+// Get winding number of ring around point
+// Positive for counterclockwise rings, negative for clockwise rings and zero outside
+// Points on the ring have an undefined winding number, so boundaries are checked separately
+func WindingNumber(r Ring, x, y float64) int {
+
+	// Track winding number
+	wn := 0
+
+	// Iterate edges
+	n := len(r)
+	for i := 0; i < n; i++ {
+		a, b := r[i], r[(i+1)%n]
+
+		// Count upward crossings with the point on the left and downward crossings with the point on the right
+		// Edges include their lower end only, so a ray through a vertex is counted once
+		if a[1] <= y {
+			if b[1] > y && orientation(a[0], a[1], b[0], b[1], x, y) > 0 {
+				wn++
+			}
+		} else if b[1] <= y && orientation(a[0], a[1], b[0], b[1], x, y) < 0 {
+			wn--
+		}
+	}
+
+	return wn
+}
+
+// Get distance from point to the nearest ring edge
+func (r Ring) Dist(x, y float64) float64 {
+
+	// Get distance to nearest edge
+	d := math.Inf(1)
+	n := len(r)
+	for i := 0; i < n; i++ {
+		a, b := r[i], r[(i+1)%n]
+		d = math.Min(d, SegmentDist(x, y, a[0], a[1], b[0], b[1]))
+	}
+
+	return d
+}
+
+// Get point location relative to ring
+// Points on an edge or within the tolerance of it are on the boundary
+// Inside uses the nonzero winding rule, so orientation of the ring doesn't matter
+func (r Ring) Locate(x, y, tolerance float64) Location {
+
+	// Check boundary
+	n := len(r)
+	for i := 0; i < n; i++ {
+		a, b := r[i], r[(i+1)%n]
+
+		// Check exactly, so points on edges are found without a tolerance
+		if orientation(a[0], a[1], b[0], b[1], x, y) == 0 && onSegment(x, y, a[0], a[1], b[0], b[1]) {
+			return Boundary
+		}
+
+		// Check within tolerance
+		if tolerance > 0 && SegmentDist(x, y, a[0], a[1], b[0], b[1]) <= tolerance {
+			return Boundary
+		}
+	}
+
+	// Check winding number
+	if WindingNumber(r, x, y) != 0 {
+		return Inside
+	}
+
+	return Outside
+}
+
+// Get point location relative to polygon
+// Boundaries of holes are boundaries of the polygon
+func (p Polygon) Locate(x, y, tolerance float64) Location {
+
+	// Check outer ring
+	if loc := p.Outer.Locate(x, y, tolerance); loc != Inside {
+		return loc
+	}
+
+	// Check holes
+	for _, h := range p.Holes {
+		switch h.Locate(x, y, tolerance) {
+		case Inside:
+			return Outside
+		case Boundary:
+			return Boundary
+		}
+	}
+
+	return Inside
+}
+
+// Get point location relative to multipolygon
+// Points inside any of the polygons are inside, even if they are on the boundary of another one
+func (m MultiPolygon) Locate(x, y, tolerance float64) Location {
+
+	// Track location
+	res := Outside
+
+	// Iterate polygons
+	for _, p := range m {
+		switch p.Locate(x, y, tolerance) {
+		case Inside:
+			return Inside
+		case Boundary:
+			res = Boundary
+		}
+	}
+
+	return res
+}
+
+// Check if multipolygon contains point
+// Points on the boundary are contained
+func (m MultiPolygon) Contains(x, y, tolerance float64) bool {
+	return m.Locate(x, y, tolerance) != Outside
+}
+
+// Get distance from point to the nearest boundary of the multipolygon, including holes
+func (m MultiPolygon) BoundaryDist(x, y float64) float64 {
+
+	// Get distance to nearest ring
+	d := math.Inf(1)
+	for _, p := range m {
+		d = math.Min(d, p.Outer.Dist(x, y))
+		for _, h := range p.Holes {
+			d = math.Min(d, h.Dist(x, y))
+		}
+	}
+
+	return d
+}
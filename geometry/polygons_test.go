@@ -0,0 +1,108 @@
+package geometry
+
+import (
+	"testing"
+)
+
+// Test point location against tricky polygons
+func TestLocate(t *testing.T) {
+
+	// Axis aligned square, which the old ray cast always rejected
+	square := Ring{{0, 0}, {0, 10}, {10, 10}, {10, 0}}
+
+	// Same square, clockwise and closed
+	closed := Ring{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+
+	// U shape, open towards positive Y, with vertices level with test points
+	u := Ring{{0, 0}, {0, 30}, {10, 30}, {10, 20}, {20, 20}, {20, 30}, {30, 30}, {30, 0}}
+
+	// Diamond, where rays pass through vertices
+	diamond := Ring{{0, 5}, {5, 10}, {10, 5}, {5, 0}}
+
+	// Square with a square hole
+	holed := MultiPolygon{{Outer: square, Holes: []Ring{{{3, 3}, {3, 7}, {7, 7}, {7, 3}}}}}
+
+	// Two squares touching at an edge, and a separate one
+	multi := MultiPolygon{
+		{Outer: square},
+		{Outer: Ring{{10, 0}, {10, 10}, {20, 10}, {20, 0}}},
+		{Outer: Ring{{30, 30}, {30, 40}, {40, 40}, {40, 30}}},
+	}
+
+	// Check locations
+	for _, c := range []struct {
+		name      string
+		polygons  MultiPolygon
+		x, y      float64
+		tolerance float64
+		expected  Location
+	}{
+		{"square center", MultiPolygon{{Outer: square}}, 5, 5, 0, Inside},
+		{"square outside", MultiPolygon{{Outer: square}}, 15, 5, 0, Outside},
+		{"square left", MultiPolygon{{Outer: square}}, -5, 5, 0, Outside},
+		{"square level with edge", MultiPolygon{{Outer: square}}, -5, 10, 0, Outside},
+		{"square vertical edge", MultiPolygon{{Outer: square}}, 5, 10, 0, Boundary},
+		{"square horizontal edge", MultiPolygon{{Outer: square}}, 10, 5, 0, Boundary},
+		{"square vertex", MultiPolygon{{Outer: square}}, 0, 0, 0, Boundary},
+		{"clockwise closed center", MultiPolygon{{Outer: closed}}, 5, 5, 0, Inside},
+		{"clockwise closed vertex", MultiPolygon{{Outer: closed}}, 10, 10, 0, Boundary},
+		{"u notch", MultiPolygon{{Outer: u}}, 15, 25, 0, Outside},
+		{"u arm", MultiPolygon{{Outer: u}}, 5, 25, 0, Inside},
+		{"u level with notch floor", MultiPolygon{{Outer: u}}, 5, 20, 0, Inside},
+		{"u notch floor", MultiPolygon{{Outer: u}}, 15, 20, 0, Boundary},
+		{"u beyond arms", MultiPolygon{{Outer: u}}, 15, 35, 0, Outside},
+		{"diamond level with vertex", MultiPolygon{{Outer: diamond}}, 2, 5, 0, Inside},
+		{"diamond outside level with vertex", MultiPolygon{{Outer: diamond}}, -2, 5, 0, Outside},
+		{"diamond corner outside", MultiPolygon{{Outer: diamond}}, 1, 1, 0, Outside},
+		{"diamond edge", MultiPolygon{{Outer: diamond}}, 2.5, 2.5, 0, Boundary},
+		{"near edge without tolerance", MultiPolygon{{Outer: square}}, 10.001, 5, 0, Outside},
+		{"near edge within tolerance", MultiPolygon{{Outer: square}}, 10.001, 5, 0.01, Boundary},
+		{"near edge inside within tolerance", MultiPolygon{{Outer: square}}, 9.999, 5, 0.01, Boundary},
+		{"hole", holed, 5, 5, 0, Outside},
+		{"between border and hole", holed, 1, 5, 0, Inside},
+		{"hole edge", holed, 3, 5, 0, Boundary},
+		{"hole vertex", holed, 7, 7, 0, Boundary},
+		{"first part", multi, 5, 5, 0, Inside},
+		{"second part", multi, 15, 5, 0, Inside},
+		{"shared edge", multi, 10, 5, 0, Boundary},
+		{"separate part", multi, 35, 35, 0, Inside},
+		{"between parts", multi, 25, 25, 0, Outside},
+		{"empty", nil, 5, 5, 0, Outside},
+	} {
+		if loc := c.polygons.Locate(c.x, c.y, c.tolerance); loc != c.expected {
+			t.Errorf("%s: expected location %d, received %d", c.name, c.expected, loc)
+		}
+		if contains := c.polygons.Contains(c.x, c.y, c.tolerance); contains != (c.expected != Outside) {
+			t.Errorf("%s: expected contains %t, received %t", c.name, c.expected != Outside, contains)
+		}
+	}
+}
+
+// Test winding number orientation and boundary distance
+func TestWindingNumber(t *testing.T) {
+
+	// Check orientation
+	ccw := Ring{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	cw := Ring{{0, 0}, {0, 10}, {10, 10}, {10, 0}}
+	if wn := WindingNumber(ccw, 5, 5); wn != 1 {
+		t.Errorf("expected winding number 1, received %d", wn)
+	}
+	if wn := WindingNumber(cw, 5, 5); wn != -1 {
+		t.Errorf("expected winding number -1, received %d", wn)
+	}
+
+	// Ring winding twice around the center
+	twice := Ring{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	if wn := WindingNumber(twice, 5, 5); wn != 2 {
+		t.Errorf("expected winding number 2, received %d", wn)
+	}
+
+	// Check distance to the nearest ring, including holes
+	holed := MultiPolygon{{Outer: ccw, Holes: []Ring{{{4, 4}, {4, 6}, {6, 6}, {6, 4}}}}}
+	if d := holed.BoundaryDist(3, 5); d != 1 {
+		t.Errorf("expected distance 1, received %g", d)
+	}
+	if d := holed.BoundaryDist(-2, 5); d != 2 {
+		t.Errorf("expected distance 2, received %g", d)
+	}
+}
@@ -4,5 +4,6 @@ go 1.22.1
 
 require (
 	github.com/go-chi/chi/v5 v5.2.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	gopkg.in/yaml.v3 v3.0.1
 )
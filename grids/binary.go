@@ -0,0 +1,161 @@
+package grids
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Binary grid format
+//
+// All values are little endian
+//
+//	Offset  Size     Type     Field
+//	0       8        [8]byte  Magic "BGSGRID1"
+//	8       8        float64  X0
+//	16      8        float64  Y0
+//	24      8        float64  DX
+//	32      8        float64  DY
+//	40      4        int32    I0, index of first row
+//	44      4        int32    J0, index of first column
+//	48      4        int32    Rows, number of nodes along X
+//	52      4        int32    Cols, number of nodes along Y
+//	56      4*R*C    float32  Values in row major order, NaN for missing nodes
+const binaryMagic = "BGSGRID1"
+
+// Max number of grid nodes of inputs with unknown size
+const maxNodes = 1 << 28
+
+// Check that a grid of rows and cols with values of the given size fits in the available bytes
+// Negative available bytes are unknown, and the grid is limited to maxNodes
+func checkNodes(rows, cols, valueSize, available int64) error {
+
+	// Check node count, rows and cols are at most 2^31 each, so the product can't overflow
+	nodes := rows * cols
+	if available < 0 {
+		if nodes > maxNodes {
+			return fmt.Errorf("grid has %d nodes, at most %d are supported", nodes, maxNodes)
+		}
+		return nil
+	}
+
+	// Check size
+	if nodes > available/valueSize {
+		return fmt.Errorf("grid header expects %d nodes, but the file has %d bytes of values", nodes, available)
+	}
+
+	return nil
+}
+
+// Binary grid header
+type binaryHeader struct {
+	Magic [8]byte
+	X0    float64
+	Y0    float64
+	DX    float64
+	DY    float64
+	I0    int32
+	J0    int32
+	Rows  int32
+	Cols  int32
+}
+
+// Load grid from binary file
+func LoadBinaryFile(path string) (*Grid, error) {
+
+	// Open file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Get file size
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBinary(bufio.NewReader(f), info.Size())
+}
+
+// Read grid in binary format
+// Size is the length of the input in bytes, used to check the header before allocating values
+// Inputs of unknown size, marked by a negative size, are limited to maxNodes values
+func ReadBinary(r io.Reader, size int64) (*Grid, error) {
+
+	// Read header
+	var h binaryHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, err
+	}
+
+	// Check header
+	if string(h.Magic[:]) != binaryMagic {
+		return nil, fmt.Errorf("%s", "not a binary grid file")
+	}
+	if h.Rows <= 0 || h.Cols <= 0 || h.DX <= 0 || h.DY <= 0 {
+		return nil, fmt.Errorf("%s", "invalid binary grid header")
+	}
+
+	// Check number of values against the input size
+	if size >= 0 {
+		size -= int64(binary.Size(h))
+	}
+	if err := checkNodes(int64(h.Rows), int64(h.Cols), 4, size); err != nil {
+		return nil, err
+	}
+
+	// Create grid
+	g := &Grid{
+		X0:     h.X0,
+		Y0:     h.Y0,
+		DX:     h.DX,
+		DY:     h.DY,
+		I0:     int(h.I0),
+		J0:     int(h.J0),
+		Rows:   int(h.Rows),
+		Cols:   int(h.Cols),
+		Values: make([]float32, int(h.Rows)*int(h.Cols)),
+	}
+
+	// Read values
+	if err := binary.Read(r, binary.LittleEndian, g.Values); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Write grid in binary format
+func WriteBinary(w io.Writer, g *Grid) error {
+
+	// Build header
+	h := binaryHeader{
+		X0:   g.X0,
+		Y0:   g.Y0,
+		DX:   g.DX,
+		DY:   g.DY,
+		I0:   int32(g.I0),
+		J0:   int32(g.J0),
+		Rows: int32(g.Rows),
+		Cols: int32(g.Cols),
+	}
+	copy(h.Magic[:], binaryMagic)
+
+	// Check size
+	if int64(g.Rows) > math.MaxInt32 || int64(g.Cols) > math.MaxInt32 {
+		return fmt.Errorf("%s", "grid is too large")
+	}
+
+	// Write header
+	if err := binary.Write(w, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+
+	// Write values
+	return binary.Write(w, binary.LittleEndian, g.Values)
+}
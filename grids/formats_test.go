@@ -0,0 +1,242 @@
+package grids
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Test reading GTX grid
+func TestGTX(t *testing.T) {
+
+	// Write header and values
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, []float64{42, 22, 0.5, 0.25})
+	binary.Write(&buf, binary.BigEndian, []int32{2, 2})
+	binary.Write(&buf, binary.BigEndian, []float32{1, 2, 3, -88.8888})
+
+	// Read grid
+	size := int64(buf.Len())
+	g, err := ReadGTX(&buf, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check values
+	if v, _ := g.At(1, 0); v != 3 {
+		t.Errorf("expected 3 at 1/0, received %v", v)
+	}
+	if _, ok := g.At(1, 1); ok {
+		t.Error("expected missing node at 1/1")
+	}
+}
+
+// Test reading ISG grid
+func TestISG(t *testing.T) {
+
+	// Create ISG 2.0 file, descriptive fields use : and numeric fields use =
+	file := strings.Join([]string{
+		"begin_of_head ================================================",
+		"model name     : TEST",
+		"model year     : 2024",
+		"model type     : gravimetric",
+		"data type      : geoid",
+		"data units     : meters",
+		"data format    : grid",
+		"data ordering  : N-to-S, W-to-E",
+		"ref ellipsoid  : GRS80",
+		"ref frame      : ETRF2000",
+		"height datum   : EVRS",
+		"tide system    : mean-tide",
+		"coord type     : geodetic",
+		"coord units    : dms",
+		"map projection : ---",
+		"EPSG code      : ---",
+		"lat min        =  42°00'00\"",
+		"lat max        =  43°00'00\"",
+		"lon min        =  22°00'00\"",
+		"lon max        =  22°30'00\"",
+		"delta lat      =  00°30'00\"",
+		"delta lon      =  00°30'00\"",
+		"nrows          =         3",
+		"ncols          =         2",
+		"nodata         =  -9999.0000",
+		"creation date  =  01/02/2024",
+		"ISG format     =       2.0",
+		"end_of_head ==================================================",
+		"  3.0  4.0",
+		"  2.0  -9999.0000",
+		"  1.0  1.5",
+	}, "\n")
+
+	// Read grid
+	g, h, err := ReadISG(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check header
+	if h.Units != "meters" || g.DX != 0.5 || g.X0 != 42 || g.Y0 != 22 {
+		t.Errorf("unexpected header %+v", h)
+	}
+
+	// Check rows are stored from south to north
+	if v, _ := g.At(0, 1); v != 1.5 {
+		t.Errorf("expected 1.5 at 0/1, received %v", v)
+	}
+	if v, _ := g.At(2, 0); v != 3 {
+		t.Errorf("expected 3 at 2/0, received %v", v)
+	}
+	if _, ok := g.At(1, 1); ok {
+		t.Error("expected missing node at 1/1")
+	}
+
+	// Read ISG 1.0 file with decimal degrees and no descriptive fields
+	file = strings.Join([]string{
+		"begin_of_head ================================================",
+		"lat min        =    42.000000",
+		"lat max        =    42.500000",
+		"lon min        =    22.000000",
+		"lon max        =    22.250000",
+		"delta lat      =     0.500000",
+		"delta lon      =     0.250000",
+		"nrows          =         2",
+		"ncols          =         2",
+		"nodata         =  -9999.0000",
+		"ISG format     =       1.0",
+		"end_of_head ==================================================",
+		"  3.0  4.0",
+		"  1.0  2.0",
+	}, "\n")
+	g, h, err = ReadISG(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.CoordUnits != "deg" || g.DY != 0.25 || g.X0 != 42 {
+		t.Errorf("unexpected header %+v", h)
+	}
+	if v, _ := g.At(1, 1); v != 4 {
+		t.Errorf("expected 4 at 1/1, received %v", v)
+	}
+}
+
+// Write NTv2 file with a sub grid covering 42-43N and 22-23E in 1 degree steps
+// Header values can be replaced, nodes have latitude shift of 1" and longitude shift of 2" west
+func ntv2File(replace map[string]any, nodes int) *bytes.Buffer {
+
+	// Write records
+	var buf bytes.Buffer
+	record := func(key string, v any) {
+		if r, ok := replace[key]; ok {
+			v = r
+		}
+		k := [8]byte{}
+		copy(k[:], key+"        ")
+		buf.Write(k[:])
+		val := [8]byte{}
+		switch v := v.(type) {
+		case int:
+			binary.LittleEndian.PutUint32(val[:], uint32(v))
+		case float64:
+			binary.LittleEndian.PutUint64(val[:], math.Float64bits(v))
+		case string:
+			copy(val[:], v+"        ")
+		}
+		buf.Write(val[:])
+	}
+
+	// Write overview header
+	record("NUM_OREC", 11)
+	record("NUM_SREC", 11)
+	record("NUM_FILE", 1)
+	record("GS_TYPE", "SECONDS")
+	record("VERSION", "NTv2.0")
+	record("SYSTEM_F", "BGS2005")
+	record("SYSTEM_T", "ETRS89")
+	record("MAJOR_F", 6378137.0)
+	record("MINOR_F", 6356752.314)
+	record("MAJOR_T", 6378137.0)
+	record("MINOR_T", 6356752.314)
+
+	// Write sub grid header
+	record("SUB_NAME", "BG")
+	record("PARENT", "NONE")
+	record("CREATED", "")
+	record("UPDATED", "")
+	record("S_LAT", 42*3600.0)
+	record("N_LAT", 43*3600.0)
+	record("E_LONG", -23*3600.0)
+	record("W_LONG", -22*3600.0)
+	record("LAT_INC", 3600.0)
+	record("LONG_INC", 3600.0)
+	record("GS_COUNT", 4)
+
+	// Write nodes
+	for i := 0; i < nodes; i++ {
+		binary.Write(&buf, binary.LittleEndian, []float32{1, 2, 0.01, 0.02})
+	}
+
+	return &buf
+}
+
+// Test reading NTv2 grid
+func TestNTv2(t *testing.T) {
+
+	// Read grid
+	buf := ntv2File(nil, 4)
+	g, err := ReadNTv2(buf, int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check header
+	if g.SystemFrom != "BGS2005" || g.SystemTo != "ETRS89" || len(g.Subgrids) != 1 {
+		t.Fatalf("unexpected header %+v", g)
+	}
+
+	// Check shift
+	dLat, dLon, ok := g.Shift(42.5, 22.5)
+	if !ok || math.Abs(dLat-1.0/3600) > 1e-12 || math.Abs(dLon+2.0/3600) > 1e-12 {
+		t.Errorf("unexpected shift %v %v %v", dLat, dLon, ok)
+	}
+
+	// Check points on the northern and eastern edges and in the north east corner
+	for _, p := range [][2]float64{{43, 22.5}, {42.5, 23}, {43, 23}} {
+		if dLat, _, ok := g.Shift(p[0], p[1]); !ok || math.Abs(dLat-1.0/3600) > 1e-12 {
+			t.Errorf("unexpected shift at %v: %v %v", p, dLat, ok)
+		}
+		if aLat, aLon, ok := g.Accuracy(p[0], p[1]); !ok || math.Abs(aLat-0.01) > 1e-6 || math.Abs(aLon-0.02) > 1e-6 {
+			t.Errorf("unexpected accuracy at %v: %v %v %v", p, aLat, aLon, ok)
+		}
+	}
+
+	// Check point outside of grid
+	if _, _, ok := g.Shift(41.5, 22.5); ok {
+		t.Error("expected no shift outside of grid")
+	}
+
+	// Read grid of unknown size
+	if _, err := ReadNTv2(ntv2File(nil, 4), -1); err != nil {
+		t.Errorf("expected grid of unknown size, received %v", err)
+	}
+
+	// Reject corrupt headers before allocating
+	for _, c := range []struct {
+		name    string
+		replace map[string]any
+		nodes   int
+	}{
+		{"inverted extent", map[string]any{"S_LAT": 44 * 3600.0, "GS_COUNT": 2}, 4},
+		{"huge extent", map[string]any{"N_LAT": 1e300}, 4},
+		{"huge count", map[string]any{"N_LAT": 42*3600.0 + 3600.0*99999, "GS_COUNT": 200000}, 4},
+		{"count mismatch", map[string]any{"GS_COUNT": 6}, 4},
+		{"missing nodes", nil, 3},
+	} {
+		buf := ntv2File(c.replace, c.nodes)
+		if _, err := ReadNTv2(buf, int64(buf.Len())); err == nil {
+			t.Errorf("%s: expected error", c.name)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package grids
+
+import (
+	"errors"
+	"math"
+)
+
+// Regular grid of values, indexed by node row and column
+// Node i/j is at X0 + i*DX, Y0 + j*DY
+type Grid struct {
+	// Origin and spacing
+	X0 float64
+	Y0 float64
+	DX float64
+	DY float64
+
+	// Index of first stored node
+	I0 int
+	J0 int
+
+	// Number of stored nodes along X and Y
+	Rows int
+	Cols int
+
+	// Values in row major order, missing nodes are NaN
+	Values []float32
+}
+
+// Point is outside of the grid
+var ErrOutOfBounds = errors.New("outside of bound")
+
+// Create grid with all nodes missing
+func New(x0, y0, dx, dy float64, i0, j0, rows, cols int) *Grid {
+
+	// Create grid
+	g := &Grid{
+		X0:     x0,
+		Y0:     y0,
+		DX:     dx,
+		DY:     dy,
+		I0:     i0,
+		J0:     j0,
+		Rows:   rows,
+		Cols:   cols,
+		Values: make([]float32, rows*cols),
+	}
+
+	// Mark nodes as missing
+	for i := range g.Values {
+		g.Values[i] = float32(math.NaN())
+	}
+
+	return g
+}
+
+// Get node value
+func (g *Grid) At(i, j int) (float64, bool) {
+
+	// Get stored index
+	r := i - g.I0
+	c := j - g.J0
+
+	// Check bounds
+	if r < 0 || r >= g.Rows || c < 0 || c >= g.Cols {
+		return 0, false
+	}
+
+	// Get value
+	v := g.Values[r*g.Cols+c]
+	if math.IsNaN(float64(v)) {
+		return 0, false
+	}
+
+	return float64(v), true
+}
+
+// Set node value
+func (g *Grid) Set(i, j int, v float64) {
+
+	// Get stored index
+	r := i - g.I0
+	c := j - g.J0
+
+	// Check bounds
+	if r < 0 || r >= g.Rows || c < 0 || c >= g.Cols {
+		return
+	}
+
+	g.Values[r*g.Cols+c] = float32(v)
+}
+
+// Get cell, containing a point, and the normalized position in the cell
+// Points on the northern or eastern edge of the grid are in the last cell, at position 1
+func (g *Grid) Cell(x, y float64) (int, int, float64, float64) {
+
+	// Get position in grid units
+	u := (x - g.X0) / g.DX
+	v := (y - g.Y0) / g.DY
+
+	// Get cell
+	i := math.Floor(u)
+	j := math.Floor(v)
+
+	// Use the last cell for points on the last row or column, which have no next node
+	if u == float64(g.I0+g.Rows-1) && g.Rows > 1 {
+		i--
+	}
+	if v == float64(g.J0+g.Cols-1) && g.Cols > 1 {
+		j--
+	}
+
+	return int(i), int(j), u - i, v - j
+}
+
+// Get grid extent
+func (g *Grid) Extent() (minX, minY, maxX, maxY float64) {
+	minX = g.X0 + float64(g.I0)*g.DX
+	minY = g.Y0 + float64(g.J0)*g.DY
+	maxX = g.X0 + float64(g.I0+g.Rows-1)*g.DX
+	maxY = g.Y0 + float64(g.J0+g.Cols-1)*g.DY
+	return
+}
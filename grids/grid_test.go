@@ -0,0 +1,101 @@
+package grids
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// Test binary format round trip
+func TestBinary(t *testing.T) {
+
+	// Create grid
+	g := New(4500000, 100000, 100, 100, -2, 3, 2, 3)
+	g.Set(-2, 3, 1.5)
+	g.Set(-1, 5, -2.25)
+
+	// Write grid
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep bytes for corrupt headers
+	data := append([]byte{}, buf.Bytes()...)
+
+	// Read grid
+	r, err := ReadBinary(&buf, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check values
+	if v, ok := r.At(-2, 3); !ok || v != 1.5 {
+		t.Errorf("expected 1.5 at -2/3, received %v %v", v, ok)
+	}
+	if v, ok := r.At(-1, 5); !ok || v != -2.25 {
+		t.Errorf("expected -2.25 at -1/5, received %v %v", v, ok)
+	}
+	if _, ok := r.At(-1, 4); ok {
+		t.Error("expected missing node at -1/4")
+	}
+	if _, ok := r.At(0, 3); ok {
+		t.Error("expected missing node outside of grid")
+	}
+
+	// Reject truncated values and header sizes beyond the input
+	if _, err := ReadBinary(bytes.NewReader(data[:len(data)-1]), int64(len(data)-1)); err == nil {
+		t.Error("expected error for truncated values")
+	}
+	huge := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(huge[48:], math.MaxInt32)
+	binary.LittleEndian.PutUint32(huge[52:], math.MaxInt32)
+	if _, err := ReadBinary(bytes.NewReader(huge), int64(len(huge))); err == nil {
+		t.Error("expected error for header larger than the file")
+	}
+	if _, err := ReadBinary(bytes.NewReader(huge), -1); err == nil {
+		t.Error("expected error for header larger than the limit")
+	}
+}
+
+// Test loading grid from sqlite database
+func TestSQLite(t *testing.T) {
+
+	// Create database
+	path := filepath.Join(t.TempDir(), "grid.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE undulation_points (id TEXT PRIMARY KEY, h REAL);
+		INSERT INTO undulation_points VALUES ('10/20', 1.0), ('11/20', 2.0), ('10/21', 3.0), ('11/21', 4.0);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Load grid
+	g, err := LoadSQLite(path, 0, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check grid
+	if g.I0 != 10 || g.J0 != 20 || g.Rows != 2 || g.Cols != 2 {
+		t.Errorf("unexpected grid bounds %d/%d %dx%d", g.I0, g.J0, g.Rows, g.Cols)
+	}
+	if v, ok := g.At(11, 21); !ok || v != 4 {
+		t.Errorf("expected 4 at 11/21, received %v %v", v, ok)
+	}
+
+	// Check cell lookup
+	i, j, xr, yr := g.Cell(1025, 2050)
+	if i != 10 || j != 20 || xr != 0.25 || yr != 0.5 {
+		t.Errorf("unexpected cell %d/%d %v %v", i, j, xr, yr)
+	}
+}
@@ -0,0 +1,96 @@
+package grids
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// GTX missing value
+const gtxNoData = -88.8888
+
+// Load grid from GTX file
+func LoadGTXFile(path string) (*Grid, error) {
+
+	// Open file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Get file size
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadGTX(bufio.NewReader(f), info.Size())
+}
+
+// Read grid in GTX format
+// GTX files have a big endian header with the south west node, spacing and size in degrees,
+// followed by float32 rows from south to north
+// Grid X is latitude and Y is longitude
+// Size is the length of the input in bytes, negative if unknown, see ReadBinary
+func ReadGTX(r io.Reader, size int64) (*Grid, error) {
+
+	// Read header
+	var h struct {
+		Lat0 float64
+		Lon0 float64
+		DLat float64
+		DLon float64
+		Rows int32
+		Cols int32
+	}
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return nil, err
+	}
+
+	// Check header
+	if h.Rows <= 0 || h.Cols <= 0 || h.DLat <= 0 || h.DLon <= 0 {
+		return nil, fmt.Errorf("%s", "invalid GTX header")
+	}
+
+	// Check number of values against the input size
+	if size >= 0 {
+		size -= int64(binary.Size(h))
+	}
+	if err := checkNodes(int64(h.Rows), int64(h.Cols), 4, size); err != nil {
+		return nil, err
+	}
+
+	// Use -180 to 180 longitudes
+	if h.Lon0 >= 180 {
+		h.Lon0 -= 360
+	}
+
+	// Create grid
+	g := &Grid{
+		X0:     h.Lat0,
+		Y0:     h.Lon0,
+		DX:     h.DLat,
+		DY:     h.DLon,
+		Rows:   int(h.Rows),
+		Cols:   int(h.Cols),
+		Values: make([]float32, int(h.Rows)*int(h.Cols)),
+	}
+
+	// Read values
+	if err := binary.Read(r, binary.BigEndian, g.Values); err != nil {
+		return nil, err
+	}
+
+	// Mark missing values
+	for i, v := range g.Values {
+		if math.Abs(float64(v)-gtxNoData) < 1e-4 {
+			g.Values[i] = float32(math.NaN())
+		}
+	}
+
+	return g, nil
+}
@@ -0,0 +1,160 @@
+package grids
+
+import (
+	"fmt"
+	"math"
+)
+
+// Interpolation methods
+const (
+	InterpolationBilinear    = "bilinear"
+	InterpolationBicubic     = "bicubic"
+	InterpolationBiquadratic = "biquadratic"
+	InterpolationNearest     = "nearest"
+)
+
+// Check if interpolation method is supported
+func ValidInterpolation(method string) error {
+	switch method {
+	case InterpolationBilinear, InterpolationBicubic, InterpolationBiquadratic, InterpolationNearest:
+		return nil
+	}
+	return fmt.Errorf("unsupported interpolation method %s", method)
+}
+
+// Interpolate at a point with a method and return the method used
+// Bicubic and biquadratic fall back to bilinear where their nodes are incomplete, e.g. near the grid edge
+func (g *Grid) Interpolate(method string, x, y float64) (float64, string, bool) {
+
+	// Interpolate with method
+	var v float64
+	var ok bool
+	switch method {
+	case InterpolationBicubic:
+		v, ok = g.Bicubic(x, y)
+	case InterpolationBiquadratic:
+		v, ok = g.Biquadratic(x, y)
+	case InterpolationNearest:
+		v, ok = g.Nearest(x, y)
+		return v, method, ok
+	default:
+		v, ok = g.Bilinear(x, y)
+		return v, InterpolationBilinear, ok
+	}
+
+	// Fall back to bilinear
+	if !ok {
+		v, ok = g.Bilinear(x, y)
+		return v, InterpolationBilinear, ok
+	}
+
+	return v, method, ok
+}
+
+// Bilinear interpolation at a point
+func (g *Grid) Bilinear(x, y float64) (float64, bool) {
+
+	// Get grid cell
+	i, j, xr, yr := g.Cell(x, y)
+
+	// Get cell vertices
+	a, ok1 := g.At(i, j)
+	b, ok2 := g.At(i+1, j)
+	c, ok3 := g.At(i, j+1)
+	d, ok4 := g.At(i+1, j+1)
+
+	// Check if all points are found
+	if !(ok1 && ok2 && ok3 && ok4) {
+		return 0, false
+	}
+
+	// Interpolate
+	return a*(1-xr)*(1-yr) + b*xr*(1-yr) + c*(1-xr)*yr + d*xr*yr, true
+}
+
+// Bicubic interpolation at a point, using cubic convolution over the 4x4 surrounding nodes
+func (g *Grid) Bicubic(x, y float64) (float64, bool) {
+
+	// Get grid cell
+	i, j, xr, yr := g.Cell(x, y)
+
+	// Get weights of nodes -1 to 2 along each axis
+	weights := func(t float64) []float64 {
+		t2, t3 := t*t, t*t*t
+		return []float64{
+			(-t3 + 2*t2 - t) / 2,
+			(3*t3 - 5*t2 + 2) / 2,
+			(-3*t3 + 4*t2 + t) / 2,
+			(t3 - t2) / 2,
+		}
+	}
+
+	return g.convolve(i-1, j-1, weights(xr), weights(yr))
+}
+
+// Biquadratic interpolation at a point, using the 3x3 nodes around the nearest node
+func (g *Grid) Biquadratic(x, y float64) (float64, bool) {
+
+	// Get position in grid units
+	u := (x - g.X0) / g.DX
+	v := (y - g.Y0) / g.DY
+
+	// Get nearest node
+	i := math.Round(u)
+	j := math.Round(v)
+
+	// Get weights of nodes -1 to 1 along each axis
+	weights := func(t float64) []float64 {
+		return []float64{
+			t * (t - 1) / 2,
+			1 - t*t,
+			t * (t + 1) / 2,
+		}
+	}
+
+	return g.convolve(int(i)-1, int(j)-1, weights(u-i), weights(v-j))
+}
+
+// Get value of the nearest node
+// Points must be within the grid extent, like with the other methods
+func (g *Grid) Nearest(x, y float64) (float64, bool) {
+
+	// Get grid cell
+	i, j, xr, yr := g.Cell(x, y)
+
+	// Check that the cell is in the grid
+	if _, ok := g.At(i, j); !ok {
+		return 0, false
+	}
+	if _, ok := g.At(i+1, j+1); !ok {
+		return 0, false
+	}
+
+	// Get nearest node of the cell
+	return g.At(i+int(math.Round(xr)), j+int(math.Round(yr)))
+}
+
+// Sum node values with separable weights, starting at node i/j
+// Fails if any of the nodes is missing
+func (g *Grid) convolve(i, j int, wx, wy []float64) (float64, bool) {
+
+	// Store result
+	res := 0.0
+
+	// Iterate nodes
+	for di, a := range wx {
+		for dj, b := range wy {
+
+			// Get value
+			v, ok := g.At(i+di, j+dj)
+			if !ok {
+				return 0, false
+			}
+
+			// Add weighted value
+			res += a * b * v
+		}
+	}
+
+	return res, true
+}
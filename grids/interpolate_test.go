@@ -0,0 +1,68 @@
+package grids
+
+import (
+	"math"
+	"testing"
+)
+
+// Test interpolation methods on a quadratic surface
+func TestInterpolate(t *testing.T) {
+
+	// Create a 5x5 grid with 50 m spacing
+	f := func(x, y float64) float64 { return 1 + 0.01*x + 0.02*y + 0.0001*x*y }
+	g := New(0, 0, 50, 50, 0, 0, 5, 5)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			g.Set(i, j, f(float64(i)*50, float64(j)*50))
+		}
+	}
+
+	// Methods that reproduce the surface
+	for _, method := range []string{InterpolationBilinear, InterpolationBicubic, InterpolationBiquadratic} {
+		v, used, ok := g.Interpolate(method, 110, 85)
+		if !ok || math.Abs(v-f(110, 85)) > 1e-4 || used != method {
+			t.Errorf("%s: expected %f, received %f %s %v", method, f(110, 85), v, used, ok)
+		}
+	}
+
+	// Nearest node
+	if v, _, _ := g.Interpolate(InterpolationNearest, 110, 85); v != f(100, 100) {
+		t.Errorf("nearest: expected %f, received %f", f(100, 100), v)
+	}
+
+	// Nearest node needs the point within the grid extent
+	if v, _, ok := g.Interpolate(InterpolationNearest, 200, 200); !ok || v != f(200, 200) {
+		t.Errorf("nearest: expected %f at the grid corner, received %f %v", f(200, 200), v, ok)
+	}
+	for _, p := range [][2]float64{{-10, 100}, {210, 100}, {100, -20}, {100, 220}} {
+		if _, _, ok := g.Interpolate(InterpolationNearest, p[0], p[1]); ok {
+			t.Errorf("nearest: expected failure outside of the grid at %v", p)
+		}
+	}
+
+	// Bicubic needs a full 4x4 neighbourhood
+	if _, ok := g.Bicubic(10, 10); ok {
+		t.Error("bicubic: expected missing neighbourhood at the grid edge")
+	}
+
+	// Bicubic and biquadratic fall back to bilinear at the grid edges
+	for _, p := range [][2]float64{{10, 10}, {190, 190}, {200, 200}, {10, 120}} {
+		expected, _ := g.Bilinear(p[0], p[1])
+		for _, method := range []string{InterpolationBicubic, InterpolationBiquadratic} {
+			v, used, ok := g.Interpolate(method, p[0], p[1])
+			if !ok || v != expected || used != InterpolationBilinear {
+				t.Errorf("%s at %v: expected bilinear %f, received %s %f %v", method, p, expected, used, v, ok)
+			}
+		}
+	}
+
+	// Points outside of the grid still fail
+	if _, _, ok := g.Interpolate(InterpolationBicubic, 210, 10); ok {
+		t.Error("bicubic: expected failure outside of the grid")
+	}
+
+	// Check method validation
+	if ValidInterpolation("spline") == nil {
+		t.Error("expected error for unsupported method")
+	}
+}
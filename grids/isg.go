@@ -0,0 +1,217 @@
+package grids
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ISG grid header
+type ISGHeader struct {
+	LatMin     float64
+	LatMax     float64
+	LonMin     float64
+	LonMax     float64
+	DLat       float64
+	DLon       float64
+	Rows       int
+	Cols       int
+	NoData     float64
+	Units      string
+	NodeType   string
+	CoordUnits string
+}
+
+// Load grid from ISG file
+func LoadISGFile(path string) (*Grid, ISGHeader, error) {
+
+	// Open file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ISGHeader{}, err
+	}
+	defer f.Close()
+
+	return ReadISG(f)
+}
+
+// Read grid in ISG 1.0 or 2.0 format
+// ISG files have a text header, closed by end_of_head, followed by rows from north to south
+// Header keys are spaced, like "lat min" and "data units"
+// Grid X is latitude and Y is longitude
+func ReadISG(r io.Reader) (*Grid, ISGHeader, error) {
+
+	// Set defaults
+	h := ISGHeader{
+		NoData:     -9999,
+		Units:      "meters",
+		NodeType:   "point",
+		CoordUnits: "deg",
+	}
+
+	// Create scanner
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	// Track header keys
+	keys := map[string]string{}
+
+	// Read header
+	for scanner.Scan() {
+
+		// Get line
+		line := strings.TrimSpace(scanner.Text())
+
+		// Exit on header end
+		if strings.HasPrefix(line, "end_of_head") {
+			break
+		}
+
+		// Get key and value
+		// Values follow = or :, ISG 2.0 uses : for descriptive fields
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			continue
+		}
+		keys[isgKey(line[:sep])] = strings.TrimSpace(line[sep+1:])
+	}
+
+	// Get coordinate units first, as they define how angles are parsed
+	if v, ok := keys["coord units"]; ok {
+		h.CoordUnits = v
+	}
+
+	// Parse header values
+	var err error
+	angles := map[string]*float64{
+		"lat min":   &h.LatMin,
+		"lat max":   &h.LatMax,
+		"lon min":   &h.LonMin,
+		"lon max":   &h.LonMax,
+		"delta lat": &h.DLat,
+		"delta lon": &h.DLon,
+	}
+	for key, dst := range angles {
+		if *dst, err = parseISGAngle(keys[key], h.CoordUnits); err != nil {
+			return nil, h, fmt.Errorf("invalid ISG %s: %w", key, err)
+		}
+	}
+	if h.Rows, err = strconv.Atoi(keys["nrows"]); err != nil {
+		return nil, h, fmt.Errorf("invalid ISG nrows: %w", err)
+	}
+	if h.Cols, err = strconv.Atoi(keys["ncols"]); err != nil {
+		return nil, h, fmt.Errorf("invalid ISG ncols: %w", err)
+	}
+	if v, ok := keys["nodata"]; ok {
+		if h.NoData, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, h, fmt.Errorf("invalid ISG nodata: %w", err)
+		}
+	}
+	if v, ok := keys["data units"]; ok {
+		h.Units = v
+	}
+	if v, ok := keys["node type"]; ok {
+		h.NodeType = v
+	}
+
+	// Check header
+	if h.Rows <= 0 || h.Cols <= 0 || h.DLat <= 0 || h.DLon <= 0 {
+		return nil, h, fmt.Errorf("%s", "invalid ISG header")
+	}
+	if err := checkNodes(int64(h.Rows), int64(h.Cols), 4, -1); err != nil {
+		return nil, h, err
+	}
+
+	// Get south west node, cell grids have nodes in cell centers
+	lat0, lon0 := h.LatMin, h.LonMin
+	if h.NodeType == "cell" {
+		lat0 += h.DLat / 2
+		lon0 += h.DLon / 2
+	}
+
+	// Create grid
+	g := New(lat0, lon0, h.DLat, h.DLon, 0, 0, h.Rows, h.Cols)
+
+	// Track node
+	n := 0
+
+	// Read values
+	for scanner.Scan() && n < h.Rows*h.Cols {
+		for _, field := range strings.Fields(scanner.Text()) {
+
+			// Parse value
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, h, fmt.Errorf("invalid ISG value '%s'", field)
+			}
+
+			// Rows go from north to south
+			i := h.Rows - 1 - n/h.Cols
+			j := n % h.Cols
+
+			// Set value
+			if v != h.NoData {
+				g.Set(i, j, v)
+			}
+
+			// Next node
+			n++
+			if n == h.Rows*h.Cols {
+				break
+			}
+		}
+	}
+
+	// Check for errors
+	if err := scanner.Err(); err != nil {
+		return nil, h, err
+	}
+	if n != h.Rows*h.Cols {
+		return nil, h, fmt.Errorf("expected %d ISG values, received %d", h.Rows*h.Cols, n)
+	}
+
+	return g, h, nil
+}
+
+// Normalize ISG header key
+// Keys are matched in lower case with single spaces, so lat_min, "lat min" and "Lat  Min" are the same key
+func isgKey(key string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(key, "_", " "))), " ")
+}
+
+// Parse ISG angle in degrees or dms
+func parseISGAngle(s string, units string) (float64, error) {
+
+	// Parse decimal degrees
+	if units != "dms" {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	// Get sign
+	sign := 1.0
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	}
+
+	// Split on degree, minute and second marks
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '°' || r == '\'' || r == '"'
+	})
+
+	// Sum parts
+	res := 0.0
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, err
+		}
+		res += v / math.Pow(60, float64(i))
+	}
+
+	return sign * res, nil
+}
@@ -0,0 +1,254 @@
+package grids
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// NTv2 horizontal shift grid file
+type NTv2 struct {
+	SystemFrom string
+	SystemTo   string
+	Subgrids   []*NTv2Subgrid
+}
+
+// NTv2 sub grid
+// Grids have latitude as X and longitude (positive east) as Y, in degrees
+// Shifts and accuracies are in arc seconds, with longitude shift positive east
+type NTv2Subgrid struct {
+	Name     string
+	Parent   string
+	LatShift *Grid
+	LonShift *Grid
+	LatAcc   *Grid
+	LonAcc   *Grid
+}
+
+// Load NTv2 file
+func LoadNTv2File(path string) (*NTv2, error) {
+
+	// Open file
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Get file size
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadNTv2(bufio.NewReader(f), info.Size())
+}
+
+// NTv2 header record
+type ntv2Record struct {
+	Key   [8]byte
+	Value [8]byte
+}
+
+// Read NTv2 header records
+func readNTv2Records(r io.Reader, order binary.ByteOrder, n int) (map[string][8]byte, error) {
+
+	// Store records
+	res := make(map[string][8]byte, n)
+
+	// Read records
+	for i := 0; i < n; i++ {
+		var rec ntv2Record
+		if err := binary.Read(r, order, &rec); err != nil {
+			return nil, err
+		}
+		res[strings.TrimSpace(string(rec.Key[:]))] = rec.Value
+	}
+
+	return res, nil
+}
+
+// Read NTv2 file
+// Size is the length of the input in bytes, negative if unknown, see ReadBinary
+func ReadNTv2(r io.Reader, size int64) (*NTv2, error) {
+
+	// Track bytes left for node values, records have 16 bytes
+	remaining := size
+	consume := func(n int64) {
+		if remaining >= 0 {
+			remaining = max(0, remaining-n)
+		}
+	}
+
+	// Read first record to detect byte order
+	var first ntv2Record
+	if err := binary.Read(r, binary.LittleEndian, &first); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(first.Key[:])) != "NUM_OREC" {
+		return nil, fmt.Errorf("%s", "not a NTv2 file")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(first.Value[:4]) != 11 {
+		order = binary.BigEndian
+	}
+	numORec := int(order.Uint32(first.Value[:4]))
+	consume(16 * int64(numORec))
+
+	// Read overview header
+	overview, err := readNTv2Records(r, order, numORec-1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get helpers
+	str := func(v [8]byte) string { return strings.TrimSpace(string(bytes.TrimRight(v[:], "\x00"))) }
+	num := func(v [8]byte) float64 { return math.Float64frombits(order.Uint64(v[:])) }
+	integer := func(v [8]byte) int { return int(int32(order.Uint32(v[:4]))) }
+
+	// Check shift units
+	if t := str(overview["GS_TYPE"]); t != "SECONDS" {
+		return nil, fmt.Errorf("unsupported NTv2 GS_TYPE %s", t)
+	}
+
+	// Create result
+	res := &NTv2{
+		SystemFrom: str(overview["SYSTEM_F"]),
+		SystemTo:   str(overview["SYSTEM_T"]),
+	}
+	numSRec := integer(overview["NUM_SREC"])
+	numFile := integer(overview["NUM_FILE"])
+
+	// Read sub grids
+	for k := 0; k < numFile; k++ {
+
+		// Read sub grid header
+		h, err := readNTv2Records(r, order, numSRec)
+		if err != nil {
+			return nil, err
+		}
+		consume(16 * int64(numSRec))
+
+		// Get extent in seconds, with longitudes positive west
+		sLat, nLat := num(h["S_LAT"]), num(h["N_LAT"])
+		eLon, wLon := num(h["E_LONG"]), num(h["W_LONG"])
+		dLat, dLon := num(h["LAT_INC"]), num(h["LONG_INC"])
+		if dLat <= 0 || dLon <= 0 {
+			return nil, fmt.Errorf("%s", "invalid NTv2 sub grid increments")
+		}
+
+		// Get size, checking the extent before converting it to node counts
+		rowsF := math.Round((nLat-sLat)/dLat) + 1
+		colsF := math.Round((wLon-eLon)/dLon) + 1
+		if !(rowsF >= 1 && rowsF <= math.MaxInt32 && colsF >= 1 && colsF <= math.MaxInt32) {
+			return nil, fmt.Errorf("%s", "invalid NTv2 sub grid extent")
+		}
+		rows, cols := int(rowsF), int(colsF)
+		if rows*cols != integer(h["GS_COUNT"]) {
+			return nil, fmt.Errorf("%s", "invalid NTv2 sub grid size")
+		}
+
+		// Check number of nodes against the input size, nodes have four float32 values
+		if err := checkNodes(int64(rows), int64(cols), 16, remaining); err != nil {
+			return nil, err
+		}
+		consume(16 * int64(rows) * int64(cols))
+
+		// Create grids with positive east longitudes
+		newGrid := func() *Grid {
+			return New(sLat/3600, -wLon/3600, dLat/3600, dLon/3600, 0, 0, rows, cols)
+		}
+		sg := &NTv2Subgrid{
+			Name:     str(h["SUB_NAME"]),
+			Parent:   str(h["PARENT"]),
+			LatShift: newGrid(),
+			LonShift: newGrid(),
+			LatAcc:   newGrid(),
+			LonAcc:   newGrid(),
+		}
+
+		// Read nodes, rows go from south to north and columns from east to west
+		var node [4]float32
+		for i := 0; i < rows; i++ {
+			for c := 0; c < cols; c++ {
+				if err := binary.Read(r, order, &node); err != nil {
+					return nil, err
+				}
+				j := cols - 1 - c
+				sg.LatShift.Set(i, j, float64(node[0]))
+				sg.LonShift.Set(i, j, -float64(node[1]))
+				sg.LatAcc.Set(i, j, float64(node[2]))
+				sg.LonAcc.Set(i, j, float64(node[3]))
+			}
+		}
+
+		// Add sub grid
+		res.Subgrids = append(res.Subgrids, sg)
+	}
+
+	return res, nil
+}
+
+// Find the densest sub grid, containing a point
+func (n *NTv2) subgrid(lat, lon float64) *NTv2Subgrid {
+
+	// Track result
+	var res *NTv2Subgrid
+
+	// Iterate sub grids
+	for _, sg := range n.Subgrids {
+
+		// Check extent
+		minX, minY, maxX, maxY := sg.LatShift.Extent()
+		if lat < minX || lat > maxX || lon < minY || lon > maxY {
+			continue
+		}
+
+		// Keep densest
+		if res == nil || sg.LatShift.DX*sg.LatShift.DY < res.LatShift.DX*res.LatShift.DY {
+			res = sg
+		}
+	}
+
+	return res
+}
+
+// Get shift at point, in degrees
+func (n *NTv2) Shift(lat, lon float64) (float64, float64, bool) {
+
+	// Get sub grid
+	sg := n.subgrid(lat, lon)
+	if sg == nil {
+		return 0, 0, false
+	}
+
+	// Interpolate shifts
+	dLat, ok1 := sg.LatShift.Bilinear(lat, lon)
+	dLon, ok2 := sg.LonShift.Bilinear(lat, lon)
+	if !(ok1 && ok2) {
+		return 0, 0, false
+	}
+
+	return dLat / 3600, dLon / 3600, true
+}
+
+// Get shift accuracy at point, in arc seconds
+func (n *NTv2) Accuracy(lat, lon float64) (float64, float64, bool) {
+
+	// Get sub grid
+	sg := n.subgrid(lat, lon)
+	if sg == nil {
+		return 0, 0, false
+	}
+
+	// Interpolate accuracies
+	aLat, ok1 := sg.LatAcc.Bilinear(lat, lon)
+	aLon, ok2 := sg.LonAcc.Bilinear(lat, lon)
+
+	return aLat, aLon, ok1 && ok2
+}
@@ -0,0 +1,99 @@
+package grids
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	// Register sqlite driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Load grid from sqlite database
+// Nodes are stored in the undulation_points table with "i/j" ids and values
+func LoadSQLite(path string, x0, y0, size float64) (*Grid, error) {
+
+	// Open DB
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Close db on end
+	defer db.Close()
+
+	// Define context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Get rows
+	rows, err := db.QueryContext(ctx, "SELECT * FROM undulation_points;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Store nodes
+	type node struct {
+		i, j int
+		h    float64
+	}
+	var nodes []node
+
+	// Track bounds
+	minI, minJ := math.MaxInt, math.MaxInt
+	maxI, maxJ := math.MinInt, math.MinInt
+
+	// Scan rows
+	for rows.Next() {
+
+		// Define base models
+		c := struct {
+			ID string
+			H  float64
+		}{}
+
+		err = rows.Scan(
+			&c.ID,
+			&c.H,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse id
+		var n node
+		if _, err := fmt.Sscanf(c.ID, "%d/%d", &n.i, &n.j); err != nil {
+			return nil, fmt.Errorf("invalid node id '%s': %w", c.ID, err)
+		}
+		n.h = c.H
+
+		// Update bounds
+		minI, maxI = min(minI, n.i), max(maxI, n.i)
+		minJ, maxJ = min(minJ, n.j), max(maxJ, n.j)
+
+		// Add node
+		nodes = append(nodes, n)
+	}
+
+	// Check for errors
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if empty
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes in %s", path)
+	}
+
+	// Create grid
+	g := New(x0, y0, size, size, minI, minJ, maxI-minI+1, maxJ-minJ+1)
+	for _, n := range nodes {
+		g.Set(n.i, n.j, n.h)
+	}
+
+	return g, nil
+}
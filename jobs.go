@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/jobs"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+	"github.com/go-chi/chi/v5"
+)
+
+// Default job retention
+const defaultJobRetention = 24 * time.Hour
+
+// Job manager
+var jobManager *jobs.Manager
+
+// Setup job manager
+func setupJobs() {
+
+	// Get settings
+	dir := app.Jobs.Dir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "bgstrans-jobs")
+	}
+	retention := app.Jobs.Retention
+	if retention == 0 {
+		retention = defaultJobRetention
+	}
+
+	// Create manager
+	var err error
+	jobManager, err = jobs.New(dir, retention, app.Jobs.Workers, runJob)
+	if err != nil {
+		log.Fatalf("Error setting up jobs: %v\n", err)
+	}
+
+	// Remove expired jobs
+	jobManager.StartCleanup(context.Background(), time.Hour)
+}
+
+// Submit transformation job
+// Accepts the same bodies as the synchronous endpoints, systems and options are read from query values
+// JSON bodies carry the systems in the request, like /v2/transform
+func submitJob(w http.ResponseWriter, r *http.Request) {
+
+	// Close response body
+	defer r.Body.Close()
+
+	// Check media type
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+	case "text/csv", "text/plain", ndjsonMediaType, geoJSONMediaType:
+
+		// Check systems before storing the input
+		if _, err := transformerFromForm(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	default:
+		http.Error(w, "Content-Type must be application/json, application/geo+json, application/x-ndjson, text/csv or text/plain", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Get parameters
+	params := map[string]string{}
+	for key, values := range r.URL.Query() {
+		params[key] = values[0]
+	}
+
+	// Submit job
+	job, err := jobManager.Submit(mediaType, params, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Write response
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// Get job status and progress
+func getJob(w http.ResponseWriter, r *http.Request) {
+
+	// Get job
+	job, err := jobManager.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Write response
+	writeJSON(w, http.StatusOK, job)
+}
+
+// Download job result
+func getJobResult(w http.ResponseWriter, r *http.Request) {
+
+	// Get result
+	job, f, err := jobManager.Result(chi.URLParam(r, "id"))
+	if errors.Is(err, jobs.ErrNotReady) {
+		http.Error(w, fmt.Sprintf("job is %s", job.Status), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	// Set headers
+	w.Header().Set("Content-Type", job.ResultType)
+	w.Header().Set("X-Total-Points", fmt.Sprint(job.Total))
+	w.Header().Set("X-Failed-Points", fmt.Sprint(job.Failed))
+
+	// Write result
+	http.ServeContent(w, r, "", *job.Finished, f)
+}
+
+// Cancel or remove job
+func deleteJob(w http.ResponseWriter, r *http.Request) {
+
+	// Delete job
+	job, err := jobManager.Delete(chi.URLParam(r, "id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Write response
+	writeJSON(w, http.StatusOK, job)
+}
+
+// Run transformation job
+func runJob(ctx context.Context, job jobs.Job, in io.Reader, out io.Writer) (jobs.Result, error) {
+
+	// Get parameter
+	get := func(key string) string {
+		return job.Params[key]
+	}
+
+	// Handle JSON requests
+	if job.ContentType == "application/json" {
+
+		// Decode request
+		var data TransfomrationRequest
+		if err := json.NewDecoder(in).Decode(&data); err != nil {
+			return jobs.Result{}, fmt.Errorf("failed to parse JSON body: %w", err)
+		}
+
+		// Transform request
+		results, summary, err := transformRequest(ctx, data)
+		if err != nil {
+			return jobs.Result{}, err
+		}
+
+		// Write response
+		res := jobs.Result{ContentType: "application/json", Total: summary.Total, Failed: summary.Failed}
+		return res, json.NewEncoder(out).Encode(newResponseV2(data, results, summary))
+	}
+
+	// Get transformer
+	transformer, err := transformerFromValues(get)
+	if err != nil {
+		return jobs.Result{}, err
+	}
+
+	// Transform input
+	var summary formats.Summary
+	res := jobs.Result{ContentType: job.ContentType}
+	switch job.ContentType {
+	case ndjsonMediaType:
+		is3D := transformations.CSKind(fmt.Sprintf("%s-%s", get("ocs"), get("ocsv"))) != transformations.KindProjected
+		summary, err = streamNDJSON(ctx, transformer, in, out, is3D, formats.DefaultChunkSize, nil)
+
+	case geoJSONMediaType:
+		opts, optsErr := formats.GeoJSONOptionsFromValues(get)
+		if optsErr != nil {
+			return res, optsErr
+		}
+		summary, err = formats.TransformGeoJSON(ctx, transformer, in, out, opts)
+
+	default:
+		opts, optsErr := formats.CSVOptionsFromValues(get)
+		if optsErr != nil {
+			return res, optsErr
+		}
+		res.ContentType = "text/csv; charset=utf-8"
+		summary, err = formats.StreamCSV(ctx, transformer, in, out, opts, formats.DefaultChunkSize, nil)
+	}
+
+	// Add summary
+	res.Total = summary.Total
+	res.Failed = summary.Failed
+
+	return res, err
+}
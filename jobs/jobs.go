@@ -0,0 +1,455 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job not found
+var ErrNotFound = errors.New("job not found")
+
+// Job result is not available
+var ErrNotReady = errors.New("job result is not ready")
+
+// Transformation job
+type Job struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+
+	// Input media type and request parameters
+	ContentType string            `json:"contentType"`
+	Params      map[string]string `json:"params,omitempty"`
+
+	// Progress as the part of the input, that is read
+	Size     int64   `json:"size"`
+	Progress float64 `json:"progress"`
+
+	// Result media type and point counts
+	ResultType string `json:"resultType,omitempty"`
+	Total      int    `json:"total"`
+	Failed     int    `json:"failed"`
+
+	Error string `json:"error,omitempty"`
+
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// Check if job is finished
+func (j Job) Done() bool {
+	return j.Status == StatusDone || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Job result
+type Result struct {
+	ContentType string
+	Total       int
+	Failed      int
+}
+
+// Job runner
+// Reads the job input from in and writes the result to out
+type RunFunc func(ctx context.Context, job Job, in io.Reader, out io.Writer) (Result, error)
+
+// Tracked job
+type entry struct {
+	job    Job
+	cancel context.CancelFunc
+	read   *atomic.Int64
+
+	// Closed when the runner exits and no longer uses the job files
+	done chan struct{}
+}
+
+// Create tracked job, that has no runner
+func newEntry(job Job) *entry {
+	done := make(chan struct{})
+	close(done)
+	return &entry{job: job, cancel: func() {}, read: &atomic.Int64{}, done: done}
+}
+
+// Check if the runner of the job exited
+func (e *entry) stopped() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Job manager with an on disk store
+type Manager struct {
+	store     *store
+	retention time.Duration
+	run       RunFunc
+
+	// Limit concurrent jobs
+	slots chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*entry
+
+	// Track runners
+	runners sync.WaitGroup
+}
+
+// Create job manager
+// Jobs, stored in dir from a previous run, are loaded, and unfinished jobs are marked as failed
+// Finished jobs are removed after the retention period
+func New(dir string, retention time.Duration, workers int, run RunFunc) (*Manager, error) {
+
+	// Create store
+	s, err := newStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use at least one worker
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// Create manager
+	m := &Manager{
+		store:     s,
+		retention: retention,
+		run:       run,
+		slots:     make(chan struct{}, workers),
+		jobs:      map[string]*entry{},
+	}
+
+	// Load stored jobs
+	stored, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range stored {
+
+		// Mark interrupted jobs as failed
+		if !job.Done() {
+			now := time.Now()
+			job.Status = StatusFailed
+			job.Error = "interrupted by server restart"
+			job.Finished = &now
+			if err := s.save(job); err != nil {
+				return nil, err
+			}
+		}
+
+		m.jobs[job.ID] = newEntry(job)
+	}
+
+	// Remove expired jobs
+	m.Cleanup()
+
+	return m, nil
+}
+
+// Remove expired jobs periodically, until the context is done
+func (m *Manager) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Cleanup()
+			}
+		}
+	}()
+}
+
+// Remove finished jobs, older than the retention period
+// Cancelled jobs are kept until their runner exits
+func (m *Manager) Cleanup() {
+
+	// Skip if jobs are kept forever
+	if m.retention <= 0 {
+		return
+	}
+
+	// Get expired jobs
+	m.mu.Lock()
+	var expired []string
+	for id, e := range m.jobs {
+		if e.job.Finished != nil && time.Since(*e.job.Finished) > m.retention && e.stopped() {
+			expired = append(expired, id)
+			delete(m.jobs, id)
+		}
+	}
+	m.mu.Unlock()
+
+	// Remove files
+	for _, id := range expired {
+		m.store.remove(id)
+	}
+}
+
+// Submit job
+// Input is stored on disk before the job is queued
+func (m *Manager) Submit(contentType string, params map[string]string, in io.Reader) (Job, error) {
+
+	// Create job
+	job := Job{
+		ID:          newID(),
+		Status:      StatusQueued,
+		ContentType: contentType,
+		Params:      params,
+		Created:     time.Now(),
+	}
+
+	// Store input
+	size, err := m.store.writeInput(job.ID, in)
+	if err != nil {
+		m.store.remove(job.ID)
+		return job, err
+	}
+	job.Size = size
+
+	// Store job
+	if err := m.store.save(job); err != nil {
+		m.store.remove(job.ID)
+		return job, err
+	}
+
+	// Track job
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{job: job, cancel: cancel, read: &atomic.Int64{}, done: make(chan struct{})}
+	m.mu.Lock()
+	m.jobs[job.ID] = e
+	m.mu.Unlock()
+
+	// Run job when a slot is free
+	m.runners.Add(1)
+	go m.process(ctx, e)
+
+	return job, nil
+}
+
+// Run job
+func (m *Manager) process(ctx context.Context, e *entry) {
+
+	// Signal exit
+	defer m.runners.Done()
+	defer close(e.done)
+
+	// Wait for a free slot
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		return
+	}
+
+	// Mark as running
+	now := time.Now()
+	job, ok := m.update(e, func(j *Job) {
+		if j.Status == StatusQueued {
+			j.Status = StatusRunning
+			j.Started = &now
+		}
+	})
+	if !ok || job.Status != StatusRunning {
+		return
+	}
+
+	// Run job
+	res, err := m.runJob(ctx, e, job)
+
+	// Store result
+	m.update(e, func(j *Job) {
+
+		// Keep cancelled and interrupted jobs as they are
+		if j.Done() {
+			return
+		}
+
+		// Update job
+		now := time.Now()
+		j.Finished = &now
+		j.ResultType = res.ContentType
+		j.Total = res.Total
+		j.Failed = res.Failed
+		if err != nil {
+			j.Status = StatusFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = StatusDone
+		j.Progress = 1
+	})
+}
+
+// Run job with input and output files
+func (m *Manager) runJob(ctx context.Context, e *entry, job Job) (Result, error) {
+
+	// Open input
+	in, err := os.Open(m.store.inputPath(job.ID))
+	if err != nil {
+		return Result{}, err
+	}
+	defer in.Close()
+
+	// Create output
+	out, err := os.Create(m.store.resultPath(job.ID))
+	if err != nil {
+		return Result{}, err
+	}
+	defer out.Close()
+
+	// Run, tracking read bytes
+	res, err := m.run(ctx, job, &countingReader{r: in, n: e.read}, out)
+	if err != nil {
+		return res, err
+	}
+
+	// Write result to disk, so a failed write fails the job instead of serving a truncated result
+	if err := out.Sync(); err != nil {
+		return res, err
+	}
+	return res, out.Close()
+}
+
+// Update tracked and stored job
+// Returns false if the job was removed
+func (m *Manager) update(e *entry, f func(j *Job)) (Job, bool) {
+
+	// Update job
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs[e.job.ID] != e {
+		return e.job, false
+	}
+	f(&e.job)
+
+	// Store job
+	m.store.save(e.job)
+
+	return e.job, true
+}
+
+// Get job
+func (m *Manager) Get(id string) (Job, error) {
+
+	// Get entry
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	// Add progress of running jobs
+	job := e.job
+	if job.Status == StatusRunning && job.Size > 0 {
+		job.Progress = float64(e.read.Load()) / float64(job.Size)
+	}
+
+	return job, nil
+}
+
+// Open job result
+func (m *Manager) Result(id string) (Job, *os.File, error) {
+
+	// Get job
+	job, err := m.Get(id)
+	if err != nil {
+		return job, nil, err
+	}
+	if job.Status != StatusDone {
+		return job, nil, ErrNotReady
+	}
+
+	// Open result
+	f, err := os.Open(m.store.resultPath(id))
+	return job, f, err
+}
+
+// Cancel unfinished job or remove finished job with its files
+// Files of a cancelled job are removed after its runner exits
+func (m *Manager) Delete(id string) (Job, error) {
+
+	// Get entry
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return Job{}, ErrNotFound
+	}
+
+	// Cancel unfinished job, keeping it until the retention period ends
+	if !e.job.Done() {
+		defer m.mu.Unlock()
+		now := time.Now()
+		e.cancel()
+		e.job.Status = StatusCancelled
+		e.job.Finished = &now
+		return e.job, m.store.save(e.job)
+	}
+
+	// Stop tracking finished job
+	delete(m.jobs, id)
+	m.mu.Unlock()
+
+	// Remove files, once the runner no longer uses them
+	<-e.done
+	return e.job, m.store.remove(id)
+}
+
+// Wait for all job runners to exit
+func (m *Manager) Wait() {
+	m.runners.Wait()
+}
+
+// Interrupt unfinished jobs and wait for their runners to exit
+// Interrupted jobs are marked as failed, like jobs interrupted by a restart
+func (m *Manager) Close() {
+
+	// Interrupt jobs
+	m.mu.Lock()
+	now := time.Now()
+	for _, e := range m.jobs {
+		if !e.job.Done() {
+			e.cancel()
+			e.job.Status = StatusFailed
+			e.job.Error = "interrupted by server shutdown"
+			e.job.Finished = &now
+			m.store.save(e.job)
+		}
+	}
+	m.mu.Unlock()
+
+	// Wait for runners
+	m.Wait()
+}
+
+// Reader, that counts read bytes
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+// Read and count bytes
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
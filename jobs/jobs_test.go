@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Copy input to output in upper case
+func upperRun(ctx context.Context, job Job, in io.Reader, out io.Writer) (Result, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return Result{}, err
+	}
+	_, err = io.WriteString(out, strings.ToUpper(string(data)))
+	return Result{ContentType: "text/plain", Total: 1}, err
+}
+
+// Wait for job to finish
+func waitJob(t *testing.T, m *Manager, id string) Job {
+	for i := 0; i < 200; i++ {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+// Test job life cycle
+func TestJobs(t *testing.T) {
+
+	// Create manager
+	dir := t.TempDir()
+	m, err := New(dir, time.Hour, 1, upperRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	// Submit job
+	job, err := m.Submit("text/plain", map[string]string{"ics": "cs1"}, strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Size != 3 {
+		t.Errorf("expected size 3, received %d", job.Size)
+	}
+
+	// Wait for result
+	job = waitJob(t, m, job.ID)
+	if job.Status != StatusDone || job.Progress != 1 || job.ResultType != "text/plain" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	// Read result
+	_, f, err := m.Result(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "ABC" {
+		t.Errorf("expected ABC, received %s", data)
+	}
+
+	// Reload jobs from disk
+	m, err = New(dir, time.Hour, 1, upperRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+	if reloaded, err := m.Get(job.ID); err != nil || reloaded.Status != StatusDone || reloaded.Params["ics"] != "cs1" {
+		t.Errorf("unexpected reloaded job %+v %v", reloaded, err)
+	}
+
+	// Remove finished job
+	if _, err := m.Delete(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(job.ID); err != ErrNotFound {
+		t.Errorf("expected %v, received %v", ErrNotFound, err)
+	}
+}
+
+// Test cancelling a running job
+func TestCancelJob(t *testing.T) {
+
+	// Create manager with a runner, that writes output after cancellation until it is released
+	started := make(chan struct{})
+	release := make(chan struct{})
+	m, err := New(t.TempDir(), time.Hour, 1, func(ctx context.Context, job Job, in io.Reader, out io.Writer) (Result, error) {
+		close(started)
+		<-ctx.Done()
+		<-release
+		io.WriteString(out, "partial")
+		return Result{}, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	// Submit job and wait for it to start
+	job, err := m.Submit("text/plain", nil, strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// Cancel job
+	if _, err := m.Delete(job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check status
+	job = waitJob(t, m, job.ID)
+	if job.Status != StatusCancelled {
+		t.Errorf("expected %s, received %s", StatusCancelled, job.Status)
+	}
+	if _, _, err := m.Result(job.ID); err != ErrNotReady {
+		t.Errorf("expected %v, received %v", ErrNotReady, err)
+	}
+
+	// Remove cancelled job, which waits for the runner to exit
+	removed := make(chan struct{})
+	go func() {
+		m.Delete(job.ID)
+		close(removed)
+	}()
+	select {
+	case <-removed:
+		t.Fatal("expected removal to wait for the runner")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-removed
+
+	// Check files are removed
+	if _, err := os.Stat(m.store.jobDir(job.ID)); !os.IsNotExist(err) {
+		t.Errorf("expected job files to be removed, received %v", err)
+	}
+}
+
+// Test interrupting running jobs
+func TestCloseJobs(t *testing.T) {
+
+	// Create manager with a runner, that waits for cancellation
+	dir := t.TempDir()
+	started := make(chan struct{})
+	m, err := New(dir, time.Hour, 1, func(ctx context.Context, job Job, in io.Reader, out io.Writer) (Result, error) {
+		close(started)
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Submit a running and a queued job
+	running, err := m.Submit("text/plain", nil, strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	queued, err := m.Submit("text/plain", nil, strings.NewReader("def"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt jobs, waiting for runners to exit
+	m.Close()
+
+	// Check jobs are failed, also after reload
+	m, err = New(dir, time.Hour, 1, upperRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+	for _, id := range []string{running.ID, queued.ID} {
+		job, err := m.Get(id)
+		if err != nil || job.Status != StatusFailed || job.Error != "interrupted by server shutdown" {
+			t.Errorf("unexpected interrupted job %+v %v", job, err)
+		}
+	}
+}
+
+// Test removing expired jobs
+func TestJobRetention(t *testing.T) {
+
+	// Create manager
+	m, err := New(t.TempDir(), time.Millisecond, 1, upperRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	// Run job
+	job, err := m.Submit("text/plain", nil, strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitJob(t, m, job.ID)
+
+	// Remove expired jobs
+	time.Sleep(5 * time.Millisecond)
+	m.Cleanup()
+	if _, err := m.Get(job.ID); err != ErrNotFound {
+		t.Errorf("expected %v, received %v", ErrNotFound, err)
+	}
+}
+
+// Test that failing to write the result to disk fails the job
+func TestJobResultWriteError(t *testing.T) {
+
+	// Create manager with a runner, that closes the result file, so it can't be synced
+	m, err := New(t.TempDir(), time.Hour, 1, func(ctx context.Context, job Job, in io.Reader, out io.Writer) (Result, error) {
+		io.WriteString(out, "partial")
+		out.(io.Closer).Close()
+		return Result{ContentType: "text/plain", Total: 1}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	// Run job
+	job, err := m.Submit("text/plain", nil, strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check job failed and has no result
+	job = waitJob(t, m, job.ID)
+	if job.Status != StatusFailed || job.Error == "" {
+		t.Errorf("expected failed job, received %+v", job)
+	}
+	if _, _, err := m.Result(job.ID); err != ErrNotReady {
+		t.Errorf("expected %v, received %v", ErrNotReady, err)
+	}
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Job file names
+const (
+	jobFile    = "job.json"
+	inputFile  = "input"
+	resultFile = "result"
+)
+
+// On disk job store
+// Every job has a directory with its metadata, input and result
+type store struct {
+	dir string
+}
+
+// Create store
+func newStore(dir string) (*store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &store{dir: dir}, nil
+}
+
+// Create random job id
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Get job directory
+func (s *store) jobDir(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// Get input file path
+func (s *store) inputPath(id string) string {
+	return filepath.Join(s.jobDir(id), inputFile)
+}
+
+// Get result file path
+func (s *store) resultPath(id string) string {
+	return filepath.Join(s.jobDir(id), resultFile)
+}
+
+// Store job input
+func (s *store) writeInput(id string, in io.Reader) (int64, error) {
+
+	// Create job directory
+	if err := os.MkdirAll(s.jobDir(id), 0o755); err != nil {
+		return 0, err
+	}
+
+	// Create file
+	f, err := os.Create(s.inputPath(id))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Copy input
+	n, err := io.Copy(f, in)
+	if err != nil {
+		return n, err
+	}
+
+	return n, f.Sync()
+}
+
+// Store job metadata
+// Metadata is written to a temporary file first, so a crash never leaves a partial file
+func (s *store) save(job Job) error {
+
+	// Encode job
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write file
+	path := filepath.Join(s.jobDir(job.ID), jobFile)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// List stored jobs
+func (s *store) list() ([]Job, error) {
+
+	// Read directory
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store result
+	var res []Job
+
+	// Read jobs
+	for _, e := range entries {
+
+		// Skip files
+		if !e.IsDir() {
+			continue
+		}
+
+		// Read metadata, skipping incomplete jobs
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name(), jobFile))
+		if err != nil {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.ID != e.Name() {
+			continue
+		}
+
+		// Add job
+		res = append(res, job)
+	}
+
+	return res, nil
+}
+
+// Remove job files
+func (s *store) remove(id string) error {
+	return os.RemoveAll(s.jobDir(id))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Newline delimited JSON media type
+const ndjsonMediaType = "application/x-ndjson"
+
+// Transform rows as they are read, writing results after every chunk
+// Accepts NDJSON rows in the /transform row layout, or delimited text with the /transform/file options
+// Systems and options are read from query values
+// Totals are sent as X-Total-Points and X-Failed-Points trailers
+// The response starts after the first chunk is transformed, so errors before that are returned with an error status
+func transformStream(w http.ResponseWriter, r *http.Request) {
+
+	// Close response body
+	defer r.Body.Close()
+
+	// Get chunk size
+	chunkSize := formats.DefaultChunkSize
+	if v := r.FormValue("chunk"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid chunk size '%s'", v), http.StatusBadRequest)
+			return
+		}
+		chunkSize = n
+	}
+
+	// Get transformer
+	transformer, err := transformerFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Allow reading the body after writing the first chunk
+	rc := http.NewResponseController(w)
+	rc.EnableFullDuplex()
+
+	// Track if the response is started
+	out := &startedWriter{w: w}
+
+	// Flush response after every chunk
+	flush := func() error {
+		out.started = true
+		return rc.Flush()
+	}
+
+	// Declare trailers
+	w.Header().Set("Trailer", "X-Total-Points, X-Failed-Points, X-Stream-Error")
+
+	// Transform rows
+	var summary formats.Summary
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case ndjsonMediaType:
+
+		// Check if output system is 3D
+		is3D := transformations.CSKind(fmt.Sprintf("%s-%s", r.FormValue("ocs"), r.FormValue("ocsv"))) != transformations.KindProjected
+
+		// Transform rows
+		w.Header().Set("Content-Type", ndjsonMediaType)
+		summary, err = streamNDJSON(r.Context(), transformer, r.Body, out, is3D, chunkSize, flush)
+
+	case "text/csv", "text/plain":
+
+		// Get file options
+		opts, optsErr := csvOptionsFromForm(r)
+		if optsErr != nil {
+			http.Error(w, optsErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Transform rows
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		summary, err = formats.StreamCSV(r.Context(), transformer, r.Body, out, opts, chunkSize, flush)
+
+	default:
+		http.Error(w, "Content-Type must be application/x-ndjson, text/csv or text/plain", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Report errors before the response is started with a status
+	if err != nil && !out.started {
+		w.Header().Del("Trailer")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Once the first chunk is written, the status can't be changed, so the error is added to the trailers
+	if err != nil {
+		w.Header().Set("X-Stream-Error", err.Error())
+	}
+
+	// Set trailers
+	w.Header().Set("X-Total-Points", strconv.Itoa(summary.Total))
+	w.Header().Set("X-Failed-Points", strconv.Itoa(summary.Failed))
+}
+
+// Transform NDJSON rows in chunks, writing every row as a structured point
+func streamNDJSON(ctx context.Context, t transformations.Transformer, in io.Reader, out io.Writer, is3D bool, chunkSize int, flush func() error) (formats.Summary, error) {
+
+	// Store summary
+	var summary formats.Summary
+
+	// Parse rows
+	parse := func(i int, fields []string) (*transformations.PointResult, bool) {
+		return formats.ParseRow(fields)
+	}
+
+	// Write rows as structured points
+	encoder := json.NewEncoder(out)
+	write := func(fields []string, pt *transformations.PointResult) error {
+
+		// Update summary for rows with coordinates
+		if len(fields) > 1 {
+			summary.Add(pt)
+		}
+
+		return encoder.Encode(newPointV2(fields, pt, is3D))
+	}
+
+	// Transform rows
+	err := formats.StreamRows(ctx, t, formats.NewNDJSONReader(in), chunkSize, parse, write, flush)
+
+	return summary, err
+}
+
+// Writer, that tracks if anything is written
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+// Write and mark as started
+func (s *startedWriter) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Post rows to the stream route and return the response with its body read, so trailers are set
+func postStream(t *testing.T, srv *httptest.Server, query, contentType, body string) (*http.Response, string) {
+
+	// Post rows
+	res, err := http.Post(srv.URL+"?ics=cs&icsv=a&ocs=cs&ocsv=b&ihs=hs1&ohs=hs2&"+query, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// Read body
+	out, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(out)
+}
+
+// Test streaming NDJSON and delimited text with totals and errors in trailers
+func TestTransformStream(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Start server
+	srv := httptest.NewServer(http.HandlerFunc(transformStream))
+	defer srv.Close()
+
+	// Stream NDJSON rows in chunks of 2
+	body := "[\"p1\",\"10\",\"20\",\"100\"]\n[\"comment\"]\n[\"p2\",\"500\",\"20\"]\n[\"p3\",\"30\",\"20\"]\n[\"p4\",\"40\",\"20\"]\n"
+	res, out := postStream(t, srv, "chunk=2", ndjsonMediaType, body)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != ndjsonMediaType {
+		t.Fatalf("unexpected response %d %s", res.StatusCode, out)
+	}
+
+	// Check rows are in input order
+	var names []string
+	var xs []float64
+	decoder := json.NewDecoder(strings.NewReader(out))
+	for decoder.More() {
+		var p PointV2
+		if err := decoder.Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, p.Name)
+		if p.X != nil {
+			xs = append(xs, *p.X)
+		}
+	}
+	if strings.Join(names, ",") != "p1,comment,p2,p3,p4" {
+		t.Errorf("expected rows in input order, received %v", names)
+	}
+	if len(xs) != 3 || xs[0] != 1010 || xs[1] != 1030 || xs[2] != 1040 {
+		t.Errorf("expected X 1010 1030 1040, received %v", xs)
+	}
+
+	// Check trailers
+	if res.Trailer.Get("X-Total-Points") != "4" || res.Trailer.Get("X-Failed-Points") != "1" || res.Trailer.Get("X-Stream-Error") != "" {
+		t.Errorf("unexpected trailers %v", res.Trailer)
+	}
+
+	// Stream NDJSON with an invalid third line
+	body = "[\"p1\",\"10\",\"20\"]\n\n{\"name\":\"p2\"}\n[\"p3\",\"30\",\"20\"]\n"
+	res, out = postStream(t, srv, "chunk=1", ndjsonMediaType, body)
+
+	// Rows before the error are written and counted, and the error is reported with its line
+	if n := strings.Count(out, "\n"); n != 2 || !strings.Contains(out, "\"p1\"") {
+		t.Errorf("expected 2 rows before the error, received %s", out)
+	}
+	if !strings.HasPrefix(res.Trailer.Get("X-Stream-Error"), "invalid row on line 3:") {
+		t.Errorf("expected error on line 3, received '%s'", res.Trailer.Get("X-Stream-Error"))
+	}
+	if res.Trailer.Get("X-Total-Points") != "1" || res.Trailer.Get("X-Failed-Points") != "0" {
+		t.Errorf("unexpected trailers %v", res.Trailer)
+	}
+
+	// Errors in the first chunk are returned with an error status instead of trailers
+	res, out = postStream(t, srv, "chunk=10", ndjsonMediaType, body)
+	if res.StatusCode != http.StatusBadRequest || !strings.HasPrefix(out, "invalid row on line 3:") {
+		t.Errorf("expected bad request for an error in the first chunk, received %d %s", res.StatusCode, out)
+	}
+	if res.Trailer.Get("X-Stream-Error") != "" || res.Trailer.Get("X-Total-Points") != "" {
+		t.Errorf("expected no trailers, received %v", res.Trailer)
+	}
+
+	// Stream delimited text
+	res, out = postStream(t, srv, "chunk=1", "text/csv", "p1,10,20\np2,500,20\n")
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(out, "p1,1010.000,20.000\n") {
+		t.Errorf("unexpected delimited text response %d %s", res.StatusCode, out)
+	}
+	if res.Trailer.Get("X-Total-Points") != "2" || res.Trailer.Get("X-Failed-Points") != "1" {
+		t.Errorf("unexpected trailers %v", res.Trailer)
+	}
+
+	// Check invalid requests
+	if res, _ := postStream(t, srv, "chunk=0", ndjsonMediaType, ""); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected bad request for invalid chunk size, received %d", res.StatusCode)
+	}
+	if res, _ := postStream(t, srv, "", "application/json", ""); res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected unsupported media type, received %d", res.StatusCode)
+	}
+}
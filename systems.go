@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+	"github.com/go-chi/chi/v5"
+)
+
+// Graphviz DOT media type
+const dotMediaType = "text/vnd.graphviz"
+
+// List coordinate systems
+func listCSs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, transformations.ListCSs())
+}
+
+// List height systems
+func listHSs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, transformations.ListHSs())
+}
+
+// Export transformation graph as JSON or Graphviz DOT
+// Query values: format (json or dot), from and to systems of a path to highlight
+func getGraph(w http.ResponseWriter, r *http.Request) {
+
+	// Export graph
+	g, err := transformations.ExportGraph(chi.URLParam(r, "kind"), r.FormValue("from"), r.FormValue("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Write response
+	switch r.FormValue("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, g)
+	case "dot":
+		w.Header().Set("Content-Type", dotMediaType)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, g.DOT())
+	default:
+		http.Error(w, "format must be json or dot", http.StatusBadRequest)
+	}
+}
+
+// Write JSON response
+func writeJSON(w http.ResponseWriter, status int, data any) {
+
+	// Set the Content-Type header to application/json
+	w.Header().Set("Content-Type", "application/json")
+
+	// Set the status code
+	w.WriteHeader(status)
+
+	// Write to response
+	json.NewEncoder(w).Encode(data)
+}
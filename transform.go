@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/formats"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Tranformation request format
+type TransfomrationRequest struct {
+	// Input systems
+	InputCS        string `json:"ics"`
+	InputCSVariant string `json:"icsv"`
+	InputHS        string `json:"ihs"`
+
+	// Output systems
+	OutputCS        string `json:"ocs"`
+	OutputCSVariant string `json:"ocsv"`
+	OutputHS        string `json:"ohs"`
+
+	// Raw data row, that can contain multiple string fields
+	// 0: No data
+	// 1: Comment
+	// 2: X, Y
+	// 3: N, X, Y
+	// >= 4: N, X, Y, H, (Various string fields)
+	Data [][]string `json:"d"`
+
+	// Return transformation steps for every point
+	Explain bool `json:"explain"`
+
+	// Blend zones for points near zone borders
+	Blend bool `json:"blend"`
+}
+
+// Transformation response format
+type TransformationResponse struct {
+	Data [][]string `json:"d"`
+
+	// Number of points and failures
+	Summary formats.Summary `json:"summary"`
+
+	// Transformation steps, aligned with data rows
+	Explain []*transformations.PointTrace `json:"explain,omitempty"`
+}
+
+// Structured transformation response format
+type TransformationResponseV2 struct {
+	Version int             `json:"version"`
+	Points  []PointV2       `json:"points"`
+	Summary formats.Summary `json:"summary"`
+}
+
+// Structured point
+type PointV2 struct {
+	// Row type: empty, comment or point
+	Type string `json:"type"`
+
+	Name string   `json:"name,omitempty"`
+	X    *float64 `json:"x,omitempty"`
+	Y    *float64 `json:"y,omitempty"`
+	Z    *float64 `json:"z,omitempty"`
+	H    *float64 `json:"h,omitempty"`
+	HasH bool     `json:"hasH"`
+
+	// Estimated standard deviations in meters, omitted if unknown
+	SigmaXY *float64 `json:"sigmaXY,omitempty"`
+	SigmaH  *float64 `json:"sigmaH,omitempty"`
+
+	// Largest distance between blended zone results
+	Discrepancy *float64 `json:"discrepancy,omitempty"`
+
+	Errors []PointError `json:"errors,omitempty"`
+	Extra  []string     `json:"extra,omitempty"`
+
+	Explain *transformations.PointTrace `json:"explain,omitempty"`
+}
+
+// Point error
+type PointError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Row types
+const (
+	RowEmpty   = "empty"
+	RowComment = "comment"
+	RowPoint   = "point"
+)
+
+// Transform data rows to formatted string rows
+func transform(w http.ResponseWriter, r *http.Request) {
+
+	// Handle GeoJSON input
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == geoJSONMediaType {
+		transformGeoJSON(w, r)
+		return
+	}
+
+	// Decode and transform request
+	data, results, summary, ok := decodeAndTransform(w, r)
+	if !ok {
+		return
+	}
+
+	// Store api result
+	var apiResult [][]string
+
+	// Store explanations
+	var explain []*transformations.PointTrace
+
+	// Iterate over points
+	for i := range data.Data {
+
+		// Get point
+		pt := results[i]
+
+		// Create output row
+		var row []string
+
+		// Add name to row
+		if len(pt.Name) > 0 {
+			row = append(row, pt.Name)
+		}
+
+		// Add coordinates or error
+		if len(pt.XYErr) > 0 {
+			row = append(row, pt.XYErr)
+		} else {
+			row = append(row, fmt.Sprintf("%.3f", pt.X))
+			row = append(row, fmt.Sprintf("%.3f", pt.Y))
+		}
+
+		// Add height or error
+		if len(pt.HErr) > 0 {
+			row = append(row, pt.HErr)
+		} else {
+			row = append(row, fmt.Sprintf("%.3f", pt.H))
+		}
+
+		// Add other fields
+		row = append(row, pt.Var...)
+
+		// Add row to api output
+		apiResult = append(apiResult, row)
+
+		// Add explanation
+		if data.Explain {
+			explain = append(explain, pt.Trace)
+		}
+	}
+
+	// Write response
+	writeJSON(w, http.StatusOK, TransformationResponse{Data: apiResult, Summary: summary, Explain: explain})
+}
+
+// Transform data rows to structured points
+func transformV2(w http.ResponseWriter, r *http.Request) {
+
+	// Decode and transform request
+	data, results, summary, ok := decodeAndTransform(w, r)
+	if !ok {
+		return
+	}
+
+	// Write response
+	writeJSON(w, http.StatusOK, newResponseV2(data, results, summary))
+}
+
+// Create structured response from request rows and their results
+func newResponseV2(data TransfomrationRequest, results map[int]*transformations.PointResult, summary formats.Summary) TransformationResponseV2 {
+
+	// Check if output system is 3D
+	is3D := transformations.CSKind(fmt.Sprintf("%s-%s", data.OutputCS, data.OutputCSVariant)) != transformations.KindProjected
+
+	// Store api result
+	res := TransformationResponseV2{
+		Version: 2,
+		Points:  make([]PointV2, 0, len(data.Data)),
+		Summary: summary,
+	}
+
+	// Iterate over rows
+	for i, line := range data.Data {
+
+		// Add point
+		res.Points = append(res.Points, newPointV2(line, results[i], is3D))
+	}
+
+	return res
+}
+
+// Create structured point from a data row and its result
+func newPointV2(line []string, pt *transformations.PointResult, is3D bool) PointV2 {
+
+	// Handle empty rows and comments
+	if len(line) == 0 {
+		return PointV2{Type: RowEmpty}
+	}
+	if len(line) == 1 {
+		return PointV2{Type: RowComment, Name: pt.Name}
+	}
+
+	// Create point
+	p := PointV2{
+		Type:    RowPoint,
+		Name:    pt.Name,
+		HasH:    pt.HasH,
+		Extra:   pt.Var,
+		Explain: pt.Trace,
+	}
+
+	// Add coordinates or error
+	if len(pt.XYErr) > 0 {
+		p.Errors = append(p.Errors, PointError{Code: pt.XYErrCode, Field: "xy", Message: pt.XYErr})
+	} else {
+		x, y := pt.X, pt.Y
+		p.X = &x
+		p.Y = &y
+
+		// Add uncertainty
+		if pt.HasSigmaXY {
+			s := pt.SigmaXY
+			p.SigmaXY = &s
+		}
+
+		// Add blend discrepancy
+		if pt.Discrepancy > 0 {
+			d := pt.Discrepancy
+			p.Discrepancy = &d
+		}
+
+		// Add third coordinate for geographic and geocentric systems
+		if is3D {
+			z := pt.Z
+			p.Z = &z
+		}
+	}
+
+	// Add height or error, heights of points with coordinate errors are not transformed
+	if len(pt.HErr) > 0 {
+		p.Errors = append(p.Errors, PointError{Code: pt.HErrCode, Field: "h", Message: pt.HErr})
+	} else if pt.HasH && len(pt.XYErr) == 0 {
+		h := pt.H
+		p.H = &h
+
+		// Add uncertainty
+		if pt.HasSigmaH {
+			s := pt.SigmaH
+			p.SigmaH = &s
+		}
+	}
+
+	return p
+}
+
+// Decode transformation request and transform all rows
+// Writes an error response and returns false on failure
+func decodeAndTransform(w http.ResponseWriter, r *http.Request) (TransfomrationRequest, map[int]*transformations.PointResult, formats.Summary, bool) {
+
+	// Store summary
+	var summary formats.Summary
+
+	// Close response body
+	defer r.Body.Close()
+
+	// Check Content-Type header, allowing parameters such as charset
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return TransfomrationRequest{}, nil, summary, false
+	}
+
+	var data TransfomrationRequest
+	err := json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		http.Error(w, "Failed to parse JSON body: "+err.Error(), http.StatusBadRequest)
+		return data, nil, summary, false
+	}
+
+	// Transform request
+	results, summary, err := transformRequest(r.Context(), data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data, nil, summary, false
+	}
+
+	return data, results, summary, true
+}
+
+// Transform all rows of a decoded request
+func transformRequest(ctx context.Context, data TransfomrationRequest) (map[int]*transformations.PointResult, formats.Summary, error) {
+
+	// Store summary
+	var summary formats.Summary
+
+	// Get CS names
+	inputCS := fmt.Sprintf("%s-%s", data.InputCS, data.InputCSVariant)
+	outputCS := fmt.Sprintf("%s-%s", data.OutputCS, data.OutputCSVariant)
+
+	// Get transformer
+	transformer, err := transformations.GetTransformer(inputCS, outputCS, data.InputHS, data.OutputHS)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	// Set transformation options
+	transformer.SetOptions(transformations.Options{Explain: data.Explain, Blend: data.Blend})
+
+	// Store output
+	results := map[int]*transformations.PointResult{}
+
+	// Iterate over data
+	for i, line := range data.Data {
+
+		// Parse row
+		pt, ok := formats.ParseRow(line)
+		results[i] = pt
+
+		// Add point for tranformation
+		if ok {
+			transformer.Add(i, pt)
+		}
+	}
+
+	// Transform data
+	_, err = transformer.TransformBatchContext(ctx)
+	if err != nil {
+		return nil, summary, err
+	}
+
+	// Update summary for rows with coordinates
+	for i, line := range data.Data {
+		if len(line) > 1 {
+			summary.Add(results[i])
+		}
+	}
+
+	return results, summary, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/transformations"
+)
+
+// Setup a single zone CS hop and a plane HS hop
+func setupTestTransformations(t *testing.T) {
+
+	// Setup app state
+	app := config.App{
+		ValidCSs: []string{"cs-a", "cs-b"},
+		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs-a": {"cs-b": {{
+				Name:   "z1",
+				Border: config.Ring{{X: 0, Y: 0}, {X: 0, Y: 100}, {X: 100, Y: 100}, {X: 100, Y: 0}},
+				A00:    1000,
+				A10:    1,
+				B01:    1,
+			}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: transformations.HSTypePlane, Name: "p12", Direction: 1}},
+		},
+		HTransformations: config.TransformationMethods{
+			Plane: map[string]config.HPlaneTransformation{"p12": {A: 1.5}},
+		},
+	}
+	if err := transformations.Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test structured response rows and error codes
+func TestResponseV2(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Transform rows
+	data := TransfomrationRequest{
+		InputCS:         "cs",
+		InputCSVariant:  "a",
+		InputHS:         "hs1",
+		OutputCS:        "cs",
+		OutputCSVariant: "b",
+		OutputHS:        "hs2",
+		Data: [][]string{
+			{},
+			{"comment"},
+			{"p1", "10", "20", "100", "code"},
+			{"10", "20"},
+			{"p2", "500", "20", "100"},
+			{"p3", "x", "20"},
+			{"p4", "10", "20", "h"},
+		},
+	}
+	results, summary, err := transformRequest(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := newResponseV2(data, results, summary)
+
+	// Check summary
+	if res.Version != 2 || len(res.Points) != len(data.Data) {
+		t.Fatalf("unexpected response %+v", res)
+	}
+	if summary.Total != 5 || summary.Failed != 3 {
+		t.Errorf("expected 5 points with 3 failures, received %+v", summary)
+	}
+
+	// Get float pointer
+	f := func(v float64) *float64 {
+		return &v
+	}
+
+	// Check points
+	expected := []PointV2{
+		{Type: RowEmpty},
+		{Type: RowComment, Name: "comment"},
+		{Type: RowPoint, Name: "p1", X: f(1010), Y: f(20), H: f(101.5), HasH: true, Extra: []string{"code"}},
+		{Type: RowPoint, X: f(1010), Y: f(20)},
+		{Type: RowPoint, Name: "p2", HasH: true, Errors: []PointError{{Code: transformations.ErrCodeOutOfBounds, Field: "xy", Message: results[4].XYErr}}},
+		{Type: RowPoint, Name: "p3", Errors: []PointError{{Code: transformations.ErrCodeParseX, Field: "xy", Message: "Error parsing 'x' as number"}}},
+		{Type: RowPoint, Name: "p4", X: f(10), Y: f(20), Errors: []PointError{{Code: transformations.ErrCodeParseH, Field: "h", Message: "Error parsing 'h' as number"}}},
+	}
+	for i, p := range res.Points {
+		if !reflect.DeepEqual(p, expected[i]) {
+			t.Errorf("row %d: expected %+v, received %+v", i, expected[i], p)
+		}
+	}
+}
+
+// Test JSON media types of transformation requests
+func TestTransformContentType(t *testing.T) {
+
+	// Setup transformations
+	setupTestTransformations(t)
+
+	// Check media types
+	body := `{"ics":"cs","icsv":"a","ihs":"hs1","ocs":"cs","ocsv":"b","ohs":"hs2","d":[["p1","10","20"]]}`
+	for _, c := range []struct {
+		contentType string
+		status      int
+	}{
+		{"application/json", http.StatusOK},
+		{"application/json; charset=utf-8", http.StatusOK},
+		{"Application/JSON", http.StatusOK},
+		{"text/plain", http.StatusUnsupportedMediaType},
+		{"", http.StatusUnsupportedMediaType},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/v2/transform", strings.NewReader(body))
+		r.Header.Set("Content-Type", c.contentType)
+		w := httptest.NewRecorder()
+		transformV2(w, r)
+		if w.Code != c.status {
+			t.Errorf("'%s': expected status %d, received %d %s", c.contentType, c.status, w.Code, w.Body.String())
+			continue
+		}
+
+		// Check transformed point
+		if c.status == http.StatusOK {
+			var res TransformationResponseV2
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res.Points) != 1 || res.Points[0].X == nil || *res.Points[0].X != 1010 {
+				t.Errorf("'%s': unexpected response %s %v", c.contentType, w.Body.String(), err)
+			}
+		}
+	}
+}
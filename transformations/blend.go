@@ -0,0 +1,204 @@
+package transformations
+
+import (
+	"errors"
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Default width of the blend band
+const defaultBlendBuffer = 50
+
+// Get width of the blend band
+func blendBuffer() float64 {
+	if Repo.App == nil || Repo.App.BlendBuffer <= 0 {
+		return defaultBlendBuffer
+	}
+	return Repo.App.BlendBuffer
+}
+
+// Result of a single zone, used for blending
+type zoneResult struct {
+	zone     int
+	x        float64
+	y        float64
+	z        float64
+	residual float64
+	weight   float64
+}
+
+// Get distance from point to zone border
+// Positive inside the zone and negative outside
+func borderDist(zone config.CSTransformation, x, y float64) float64 {
+
+	// Get distance to nearest border, including holes and parts
+	polygons := zone.Polygons()
+	d := polygons.BoundaryDist(x, y)
+
+	// Add sign
+	if !polygons.Contains(x, y, borderTolerance()) {
+		return -d
+	}
+	return d
+}
+
+// Get zone weight from signed border distance
+// Falls linearly from 1 at buffer inside the border to 0 at buffer outside it
+func blendWeight(d, buffer float64) float64 {
+	return math.Max(0, math.Min(1, (d+buffer)/(2*buffer)))
+}
+
+// Transform point with all bordered zones, that are within the buffer of their borders
+// Zone weights are normalized
+// Returns no results if the point is not inside any of the zones, with the first error of a containing or derived inverse zone
+func blendZones(zones []config.CSTransformation, x, y, z, buffer float64) ([]zoneResult, error) {
+
+	// Store results
+	var res []zoneResult
+	var firstErr error
+	inside := false
+
+	// Iterate over zones
+	for i, zone := range zones {
+
+		// Skip zones without a border
+		if len(zone.Border) == 0 {
+			continue
+		}
+
+		// Get distance of forward zones before transforming, skipping far zones
+		d := 0.0
+		if !zone.Inverse {
+			d = borderDist(zone, x, y)
+			if d <= -buffer {
+				continue
+			}
+		}
+
+		// Transform point
+		r := zoneResult{zone: i}
+		var err error
+		if zone.Inverse {
+			r.x, r.y, r.z, r.residual, err = inverseZone(zone, x, y, z)
+		} else {
+			r.x, r.y, r.z, err = forwardZone(zone, x, y, z)
+		}
+		if errors.Is(err, grids.ErrOutOfBounds) {
+			continue
+		}
+		if err != nil {
+
+			// Report errors of zones, that would be used without blending
+			if firstErr == nil && d >= 0 {
+				firstErr = err
+			}
+			continue
+		}
+
+		// Get distance of inverse zones in the forward source system
+		if zone.Inverse {
+			d = borderDist(zone, r.x, r.y)
+			if d <= -buffer {
+				continue
+			}
+		}
+		inside = inside || d >= 0
+
+		// Add result
+		r.weight = blendWeight(d, buffer)
+		res = append(res, r)
+	}
+
+	// Require a zone, that contains the point
+	if !inside {
+		return nil, firstErr
+	}
+
+	// Normalize weights
+	sum := 0.0
+	for _, r := range res {
+		sum += r.weight
+	}
+	for i := range res {
+		res[i].weight /= sum
+	}
+
+	return res, nil
+}
+
+// Combine zone results by their weights
+// Returns the largest horizontal distance between zone results
+func combineZones(res []zoneResult) (float64, float64, float64, float64) {
+
+	// Get weighted sums
+	var x, y, z float64
+	for _, r := range res {
+		x += r.weight * r.x
+		y += r.weight * r.y
+		z += r.weight * r.z
+	}
+
+	// Get discrepancy
+	discrepancy := 0.0
+	for i := range res {
+		for j := i + 1; j < len(res); j++ {
+			discrepancy = math.Max(discrepancy, math.Hypot(res[i].x-res[j].x, res[i].y-res[j].y))
+		}
+	}
+
+	return x, y, z, discrepancy
+}
+
+// Get weighted standard deviation of blended zones
+// Returns false if a zone has no stated accuracy
+func blendSigma(zones []config.CSTransformation, res []zoneResult, x, y float64) (float64, bool) {
+
+	// Sum weighted standard deviations
+	sigma := 0.0
+	known := true
+	for _, r := range res {
+		s, ok := zoneSigma(zones[r.zone], x, y)
+		sigma += r.weight * s
+		known = known && ok
+	}
+
+	return sigma, known
+}
+
+// Record zones and step of a blended hop
+func (pt *PointResult) traceBlend(from, to string, zones []config.CSTransformation, res []zoneResult, hop CSHopTrace) {
+
+	// Iterate over zone results
+	best := 0.0
+	for _, r := range res {
+
+		// Record zone
+		zone := zones[r.zone]
+		pt.Zones = append(pt.Zones, ZoneHit{From: from, To: to, Zone: r.zone, Name: zone.Name, Weight: r.weight})
+
+		// Use zone with the largest weight as the hop zone
+		if r.weight > best {
+			best = r.weight
+			hop.Zone = r.zone
+			hop.ZoneName = zone.Name
+		}
+
+		// Add zone result
+		hop.Blend = append(hop.Blend, BlendTrace{
+			Zone:     r.zone,
+			ZoneName: zone.Name,
+			Weight:   r.weight,
+			X:        r.x,
+			Y:        r.y,
+			Z:        r.z,
+			Residual: r.residual,
+		})
+	}
+
+	// Record step
+	if pt.Trace != nil {
+		pt.Trace.CSHops = append(pt.Trace.CSHops, hop)
+	}
+}
@@ -0,0 +1,141 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Test zone weights and combination of zone results
+func TestBlend(t *testing.T) {
+
+	// Check weights across the blend band
+	for _, c := range []struct{ d, expected float64 }{
+		{-80, 0},
+		{-50, 0},
+		{-25, 0.25},
+		{0, 0.5},
+		{25, 0.75},
+		{50, 1},
+		{80, 1},
+	} {
+		if w := blendWeight(c.d, 50); math.Abs(w-c.expected) > 1e-12 {
+			t.Errorf("distance %g: expected weight %g, received %g", c.d, c.expected, w)
+		}
+	}
+
+	// Combine two zones at a quarter of the band outside the first zone
+	res := []zoneResult{
+		{zone: 0, x: 101, y: 50, z: 10, weight: 0.25},
+		{zone: 1, x: 105, y: 53, z: 10, weight: 0.75},
+	}
+	x, y, z, discrepancy := combineZones(res)
+	if math.Abs(x-104) > 1e-12 || math.Abs(y-52.25) > 1e-12 || z != 10 {
+		t.Errorf("expected 104 52.25 10, received %g %g %g", x, y, z)
+	}
+	if math.Abs(discrepancy-5) > 1e-12 {
+		t.Errorf("expected discrepancy 5, received %g", discrepancy)
+	}
+}
+
+// Test zone selection and blending with holes and multipolygon zones
+func TestZoneBorders(t *testing.T) {
+
+	// Setup two zones, the second covering the hole of the first one with a part
+	hole := squareBorder(40, 40, 20)
+	app := config.App{
+		ValidCSs:    []string{"cs1", "cs2"},
+		BlendBuffer: 10,
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {
+				{Name: "a", Border: squareBorder(0, 0, 100), Holes: []config.Ring{hole}, A00: 1000, A10: 1, B01: 1},
+				{Name: "b", Border: squareBorder(100, 0, 100), Parts: []config.BorderPart{{Border: hole}}, A00: 2000, A10: 1, B01: 1},
+			}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Get transformer
+	tr, err := GetCSTransformer("cs1", "cs2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check zones, points on the shared border use the first zone
+	for _, c := range []struct {
+		x, y     float64
+		expected float64
+	}{
+		{50, 10, 1050},
+		{50, 50, 2050},
+		{40, 50, 1040},
+		{150, 50, 2150},
+		{100, 50, 1100},
+		{300, 50, 0},
+	} {
+		tr.Reset()
+		tr.Add(0, &PointResult{X: c.x, Y: c.y})
+		res, err := tr.TransformBatch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pt := res[0]; c.expected == 0 && pt.XYErrCode != ErrCodeOutOfBounds {
+			t.Errorf("%g %g: expected %s error, received '%s'", c.x, c.y, ErrCodeOutOfBounds, pt.XYErrCode)
+		} else if c.expected != 0 && (pt.X != c.expected || len(pt.XYErr) > 0) {
+			t.Errorf("%g %g: expected %g, received %g %s", c.x, c.y, c.expected, pt.X, pt.XYErr)
+		}
+	}
+
+	// Blend a point a quarter of the band outside the first zone
+	tr.Reset()
+	tr.SetOptions(Options{Blend: true})
+	tr.Add(0, &PointResult{X: 105, Y: 50})
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt := res[0]; math.Abs(pt.X-1855) > 1e-9 || math.Abs(pt.Discrepancy-1000) > 1e-9 {
+		t.Errorf("expected 1855 with discrepancy 1000, received %g %g", pt.X, pt.Discrepancy)
+	}
+}
+
+// Test that failed derived inverses are reported when blending
+func TestBlendErrors(t *testing.T) {
+
+	// Setup a nonlinear invertible zone, that can't be solved in one iteration
+	app := config.App{
+		ValidCSs:             []string{"cs1", "cs2"},
+		ValidHSs:             []string{"hs1"},
+		BlendBuffer:          10,
+		InverseTolerance:     1e-12,
+		InverseMaxIterations: 1,
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {
+				{Name: "a", Type: CSTypePolynomial, Border: squareBorder(0, 0, 100), A00: 1000, A10: 1, A20: 0.001, B01: 1, Invertible: true},
+			}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Transform through the derived inverse with and without blending
+	for _, blend := range []bool{false, true} {
+		tr, err := GetTransformer("cs2", "cs1", "hs1", "hs1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.SetOptions(Options{Blend: blend})
+		tr.Add(0, &PointResult{X: 1052.5, Y: 50})
+		res, err := tr.TransformBatch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pt := res[0]; pt.XYErrCode != ErrCodeNotConverged {
+			t.Errorf("blend %t: expected %s error, received '%s' %g %g", blend, ErrCodeNotConverged, pt.XYErrCode, pt.X, pt.Y)
+		}
+	}
+}
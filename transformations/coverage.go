@@ -0,0 +1,81 @@
+package transformations
+
+import (
+	"fmt"
+)
+
+// Grid transformation coverage
+type GridCoverage struct {
+	Name string `json:"name"`
+
+	// System of grid nodes
+	CS string `json:"cs"`
+
+	// Extent of grid nodes
+	MinX float64 `json:"minX"`
+	MinY float64 `json:"minY"`
+	MaxX float64 `json:"maxX"`
+	MaxY float64 `json:"maxY"`
+
+	// Node spacing and interpolation method
+	DX            float64 `json:"dx"`
+	DY            float64 `json:"dy"`
+	Interpolation string  `json:"interpolation"`
+}
+
+// List coverage of loaded grid transformations, sorted by name
+func GridCoverages() []GridCoverage {
+
+	// Store result
+	res := make([]GridCoverage, 0, len(Repo.Grids))
+
+	// Iterate over grids
+	for _, name := range sortedKeys(Repo.Grids) {
+
+		// Get grid
+		g := Repo.Grids[name]
+		params := Repo.App.HTransformations.Grid[name]
+
+		// Add coverage
+		minX, minY, maxX, maxY := g.Extent()
+		res = append(res, GridCoverage{
+			Name:          name,
+			CS:            gridSystem(params),
+			MinX:          minX,
+			MinY:          minY,
+			MaxX:          maxX,
+			MaxY:          maxY,
+			DX:            g.DX,
+			DY:            g.DY,
+			Interpolation: gridMethod(params),
+		})
+	}
+
+	return res
+}
+
+// Get transformer for horizontal coordinates only, heights are kept
+// Input system can be any system of the CS graph, so zones of intermediate hops can be displayed
+func GetCSTransformer(ics, ocs string) (Transformer, error) {
+
+	// Validate input
+	if !Repo.ValidCSs[ics] && !graphNodes(Repo.CSGraph.data)[ics] {
+		return nil, fmt.Errorf("%s", "Invalid input CS")
+	}
+	if _, ok := Repo.ValidCSs[ocs]; !ok {
+		return nil, fmt.Errorf("%s", "Invalid output CS")
+	}
+
+	// Find path from input CS to output CS
+	csPath, found := findPathGraph(Repo.CSGraph.data, ics, [2]string{ocs})
+	if !found {
+		return nil, fmt.Errorf("can't convert from %s to %s", ics, ocs)
+	}
+
+	return &TransformerOutput{
+		csPath: csPath,
+		ics:    ics,
+		ocs:    ocs,
+		points: make(map[int]*PointResult),
+	}, nil
+}
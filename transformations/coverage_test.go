@@ -0,0 +1,59 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Test grid coverage and horizontal transformation from intermediate systems
+func TestCoverage(t *testing.T) {
+
+	// Setup app state with an intermediate system
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs3"},
+		ValidHSs: []string{"hs1"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {{Type: CSTypeAffine, A00: 100, A10: 1, B01: 1, Invertible: true}}},
+			"cs2": {"cs3": {{Type: CSTypeAffine, B00: 200, A10: 1, B01: 1, Invertible: true}}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs3", Interpolation: grids.InterpolationBicubic}}
+	Repo.Grids = map[string]*grids.Grid{"geoid": grids.New(1000, 2000, 10, 20, 1, 2, 3, 4)}
+
+	// Check coverage
+	c := GridCoverages()
+	expected := GridCoverage{Name: "geoid", CS: "cs3", MinX: 1010, MinY: 2040, MaxX: 1030, MaxY: 2100, DX: 10, DY: 20, Interpolation: grids.InterpolationBicubic}
+	if len(c) != 1 || c[0] != expected {
+		t.Errorf("expected coverage %+v, received %+v", expected, c)
+	}
+
+	// Transform from intermediate system
+	tr, err := GetCSTransformer("cs2", "cs1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Add(0, &PointResult{X: 150, Y: 50})
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt := res[0]; math.Abs(pt.X-50) > 1e-9 || math.Abs(pt.Y-50) > 1e-9 || len(pt.XYErr) > 0 {
+		t.Errorf("expected 50 50, received %f %f %s", pt.X, pt.Y, pt.XYErr)
+	}
+
+	// Reject unknown systems
+	if _, err := GetCSTransformer("cs4", "cs1"); err == nil {
+		t.Error("expected error for unknown input system")
+	}
+	if _, err := GetCSTransformer("cs1", "cs2"); err == nil {
+		t.Error("expected error for hidden output system")
+	}
+}
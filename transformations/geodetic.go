@@ -0,0 +1,148 @@
+package transformations
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Coordinate system kinds
+const (
+	KindProjected  = "projected"
+	KindGeographic = "geographic"
+	KindGeocentric = "geocentric"
+)
+
+// Predefined ellipsoids
+var defaultEllipsoids = map[string]config.Ellipsoid{
+	"GRS80":     {A: 6378137, InvF: 298.257222101},
+	"WGS84":     {A: 6378137, InvF: 298.257223563},
+	"Krasovsky": {A: 6378245, InvF: 298.3},
+}
+
+// Get coordinate system kind, defaulting to projected
+func CSKind(cs string) string {
+	if kind := Repo.App.CSDefinitions[cs].Kind; kind != "" {
+		return kind
+	}
+	return KindProjected
+}
+
+// Get coordinate system ellipsoid
+func csEllipsoid(cs string) (config.Ellipsoid, error) {
+
+	// Get ellipsoid name
+	name := Repo.App.CSDefinitions[cs].Ellipsoid
+
+	// Get ellipsoid
+	e, ok := Repo.Ellipsoids[name]
+	if !ok {
+		return e, fmt.Errorf("unknown ellipsoid '%s' for %s", name, cs)
+	}
+
+	return e, nil
+}
+
+// Convert coordinates between systems of different kinds
+// Geographic coordinates are latitude (X) and longitude (Y) in degrees, with ellipsoidal height as Z
+// Projected coordinates are northing (X) and easting (Y)
+func convert(from, to string, x, y, z float64) (float64, float64, float64, error) {
+
+	// Get kinds
+	fromKind := CSKind(from)
+	toKind := CSKind(to)
+
+	// Get ellipsoid
+	e, err := csEllipsoid(from)
+	if err != nil {
+		return x, y, z, err
+	}
+
+	// Conversion must keep the ellipsoid
+	if te, err := csEllipsoid(to); err != nil || te != e {
+		return x, y, z, fmt.Errorf("can't convert between %s and %s on different ellipsoids", from, to)
+	}
+
+	// Convert
+	switch {
+	case fromKind == KindGeographic && toKind == KindGeocentric:
+		rx, ry, rz := geographicToGeocentric(e, x, y, z)
+		return rx, ry, rz, nil
+	case fromKind == KindGeocentric && toKind == KindGeographic:
+		rx, ry, rz := geocentricToGeographic(e, x, y, z)
+		return rx, ry, rz, nil
+	case fromKind == KindGeographic && toKind == KindProjected:
+		p, err := csProjection(to)
+		if err != nil {
+			return x, y, z, err
+		}
+		rx, ry, err := p.forward(x, y)
+		return rx, ry, z, err
+	case fromKind == KindProjected && toKind == KindGeographic:
+		p, err := csProjection(from)
+		if err != nil {
+			return x, y, z, err
+		}
+		rx, ry, err := p.inverse(x, y)
+		return rx, ry, z, err
+	}
+
+	return x, y, z, fmt.Errorf("unsupported conversion from %s to %s", fromKind, toKind)
+}
+
+// Get ellipsoid first eccentricity squared
+func eccentricity2(e config.Ellipsoid) float64 {
+	f := 1 / e.InvF
+	return f * (2 - f)
+}
+
+// Convert geographic coordinates to geocentric
+func geographicToGeocentric(e config.Ellipsoid, lat, lon, h float64) (float64, float64, float64) {
+
+	// Convert to radians
+	phi := lat * math.Pi / 180
+	lambda := lon * math.Pi / 180
+
+	// Get prime vertical radius of curvature
+	e2 := eccentricity2(e)
+	n := e.A / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+
+	// Convert
+	x := (n + h) * math.Cos(phi) * math.Cos(lambda)
+	y := (n + h) * math.Cos(phi) * math.Sin(lambda)
+	z := (n*(1-e2) + h) * math.Sin(phi)
+
+	return x, y, z
+}
+
+// Convert geocentric coordinates to geographic, using Bowring's method
+func geocentricToGeographic(e config.Ellipsoid, x, y, z float64) (float64, float64, float64) {
+
+	// Get ellipsoid parameters
+	e2 := eccentricity2(e)
+	b := e.A * (1 - 1/e.InvF)
+	ep2 := (e.A*e.A - b*b) / (b * b)
+
+	// Get distance from axis
+	p := math.Hypot(x, y)
+
+	// Get parametric latitude and latitude
+	theta := math.Atan2(z*e.A, p*b)
+	phi := math.Atan2(
+		z+ep2*b*math.Pow(math.Sin(theta), 3),
+		p-e2*e.A*math.Pow(math.Cos(theta), 3),
+	)
+	lambda := math.Atan2(y, x)
+
+	// Get height
+	n := e.A / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	h := p/math.Cos(phi) - n
+
+	// Use Z based height near the poles
+	if math.Abs(math.Cos(phi)) < 1e-9 {
+		h = math.Abs(z) - b
+	}
+
+	return phi * 180 / math.Pi, lambda * 180 / math.Pi, h
+}
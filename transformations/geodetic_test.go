@@ -0,0 +1,78 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Test geographic to geocentric conversion against the EPSG guidance note example
+func TestGeocentric(t *testing.T) {
+
+	// Get ellipsoid
+	e := defaultEllipsoids["WGS84"]
+
+	// Define geographic point
+	lat := 53 + 48/60.0 + 33.82/3600
+	lon := 2 + 7/60.0 + 46.38/3600
+	h := 73.0
+
+	// Convert to geocentric
+	x, y, z := geographicToGeocentric(e, lat, lon, h)
+	if math.Abs(x-3771793.968) > 0.001 || math.Abs(y-140253.342) > 0.001 || math.Abs(z-5124304.349) > 0.001 {
+		t.Errorf("expected 3771793.968, 140253.342, 5124304.349; received %.3f, %.3f, %.3f", x, y, z)
+	}
+
+	// Convert back
+	rlat, rlon, rh := geocentricToGeographic(e, x, y, z)
+	if math.Abs(rlat-lat) > 1e-9 || math.Abs(rlon-lon) > 1e-9 || math.Abs(rh-h) > 0.001 {
+		t.Errorf("expected %.9f, %.9f, %.3f; received %.9f, %.9f, %.3f", lat, lon, h, rlat, rlon, rh)
+	}
+}
+
+// Test that geographic to geocentric conversion uses the input Z, or the height as ellipsoidal height
+func TestGeocentricHeight(t *testing.T) {
+
+	// Setup app state with a conversion hop
+	app := config.App{
+		ValidCSs: []string{"geo", "ecef"},
+		ValidHSs: []string{"hs1"},
+		CSDefinitions: map[string]config.CSDefinition{
+			"geo":  {Kind: KindGeographic, Ellipsoid: "WGS84"},
+			"ecef": {Kind: KindGeocentric, Ellipsoid: "WGS84"},
+		},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"geo": {"ecef": {{Type: CSTypeConversion}}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Transform points with and without Z
+	tr, err := GetTransformer("geo", "ecef", "hs1", "hs1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.SetOptions(Options{Explain: true})
+	tr.Add(0, &PointResult{X: 42, Y: 25, H: 500, HasH: true})
+	tr.Add(1, &PointResult{X: 42, Y: 25, H: 500, HasH: true, Z: 540, HasZ: true})
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check used heights
+	for i, h := range []float64{500, 540} {
+		x, y, z := geographicToGeocentric(defaultEllipsoids["WGS84"], 42, 25, h)
+		if pt := res[i]; math.Abs(pt.X-x) > 1e-6 || math.Abs(pt.Y-y) > 1e-6 || math.Abs(pt.Z-z) > 1e-6 {
+			t.Errorf("point %d: expected %.3f, %.3f, %.3f; received %.3f, %.3f, %.3f", i, x, y, z, pt.X, pt.Y, pt.Z)
+		}
+	}
+
+	// Check that the assumption is reported only for the point without Z
+	if !res[0].Trace.CSHops[0].HeightAsEllipsoidal || res[1].Trace.CSHops[0].HeightAsEllipsoidal {
+		t.Errorf("expected height used as ellipsoidal only without Z, received %+v and %+v", res[0].Trace.CSHops[0], res[1].Trace.CSHops[0])
+	}
+}
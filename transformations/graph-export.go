@@ -0,0 +1,205 @@
+package transformations
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Graph kinds
+const (
+	GraphCS = "cs"
+	GraphHS = "hs"
+)
+
+// Exported transformation graph
+type GraphExport struct {
+	Kind  string      `json:"kind"`
+	Nodes []GraphNode `json:"nodes"`
+
+	// Outgoing edges by source system
+	Adjacency map[string][]GraphEdge `json:"adjacency"`
+
+	// Systems on the highlighted path, in order
+	Path []string `json:"path,omitempty"`
+}
+
+// Graph node
+type GraphNode struct {
+	ID string `json:"id"`
+
+	// System is exposed by the API
+	Valid bool `json:"valid"`
+
+	// System is on the highlighted path
+	Highlight bool `json:"highlight,omitempty"`
+}
+
+// Graph edge
+type GraphEdge struct {
+	To string `json:"to"`
+
+	// Transformation methods
+	Types []string `json:"types"`
+
+	// Number of zones and if the edge is derived from an invertible reverse edge, for CS edges
+	Zones   int  `json:"zones,omitempty"`
+	Derived bool `json:"derived,omitempty"`
+
+	// Method name and direction, for HS edges
+	Name      string  `json:"name,omitempty"`
+	Direction float64 `json:"direction,omitempty"`
+
+	// Stated accuracy in meters, CS edges use their least accurate zone
+	Accuracy float64 `json:"accuracy,omitempty"`
+
+	// Edge is on the highlighted path
+	Highlight bool `json:"highlight,omitempty"`
+}
+
+// Export transformation graph
+// If from and to are set, the path between them is highlighted
+func ExportGraph(kind, from, to string) (GraphExport, error) {
+	switch kind {
+	case GraphCS:
+		return exportGraph(kind, Repo.CSGraph.data, Repo.ValidCSs, from, to, csEdge)
+	case GraphHS:
+		return exportGraph(kind, Repo.HSGraph.data, Repo.ValidHSs, from, to, hsEdge)
+	}
+	return GraphExport{}, fmt.Errorf("unknown graph '%s'", kind)
+}
+
+// Describe CS edge
+func csEdge(from, to string, zones []config.CSTransformation) GraphEdge {
+
+	// Get zone methods
+	e := GraphEdge{Zones: len(zones)}
+	for _, zone := range zones {
+		if t := zoneType(zone); !slices.Contains(e.Types, t) {
+			e.Types = append(e.Types, t)
+		}
+	}
+
+	// Check if edge is not configured
+	_, configured := Repo.App.CsGraph[from][to]
+	e.Derived = !configured
+
+	return e
+}
+
+// Describe HS edge
+func hsEdge(from, to string, params config.HSTransformation) GraphEdge {
+	return GraphEdge{
+		Types:     []string{params.Type},
+		Name:      params.Name,
+		Direction: params.Direction,
+	}
+}
+
+// Export graph with highlighted path
+func exportGraph[T any](kind string, graph map[string]map[string]T, valid map[string]bool, from, to string, describe func(from, to string, edge T) GraphEdge) (GraphExport, error) {
+
+	// Store result
+	res := GraphExport{Kind: kind, Nodes: []GraphNode{}, Adjacency: map[string][]GraphEdge{}}
+
+	// Get highlighted path
+	var path map[string][]string
+	if from != "" || to != "" {
+
+		// Validate systems
+		if !valid[from] || !valid[to] {
+			return res, fmt.Errorf("invalid systems %s and %s", from, to)
+		}
+
+		// Find path
+		var found bool
+		path, found = findPathGraph(graph, from, [2]string{to})
+		if !found {
+			return res, fmt.Errorf("no path from %s to %s", from, to)
+		}
+		res.Path = walkPath(path, from)
+	}
+
+	// Add nodes, including valid systems without edges
+	nodes := graphNodes(graph)
+	for node := range valid {
+		nodes[node] = true
+	}
+	for _, node := range sortedKeys(nodes) {
+		res.Nodes = append(res.Nodes, GraphNode{
+			ID:        node,
+			Valid:     valid[node],
+			Highlight: slices.Contains(res.Path, node),
+		})
+	}
+
+	// Add edges
+	for _, f := range sortedKeys(graph) {
+		for _, t := range sortedConnections(graph, f) {
+			e := describe(f, t, graph[f][t])
+			e.To = t
+			e.Accuracy = edgeAccuracy(graph[f][t])
+			e.Highlight = slices.Contains(path[f], t)
+			res.Adjacency[f] = append(res.Adjacency[f], e)
+		}
+	}
+
+	return res, nil
+}
+
+// Render graph in Graphviz DOT format
+// Exposed systems are boxes, derived edges are dashed and the highlighted path is red
+func (g GraphExport) DOT() string {
+
+	// Start graph
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", g.Kind)
+	b.WriteString("\trankdir=LR;\n")
+
+	// Add nodes
+	for _, n := range g.Nodes {
+		attrs := []string{"shape=ellipse", "style=dashed"}
+		if n.Valid {
+			attrs = []string{"shape=box"}
+		}
+		if n.Highlight {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&b, "\t%q [%s];\n", n.ID, strings.Join(attrs, ", "))
+	}
+
+	// Add edges
+	for _, n := range g.Nodes {
+		for _, e := range g.Adjacency[n.ID] {
+
+			// Get label
+			label := strings.Join(e.Types, "/")
+			if e.Zones > 1 {
+				label += fmt.Sprintf(" x%d", e.Zones)
+			}
+			if e.Name != "" {
+				label += fmt.Sprintf(" %s (%+g)", e.Name, e.Direction)
+			}
+			if e.Accuracy > 0 {
+				label += fmt.Sprintf("\n%g m", e.Accuracy)
+			}
+
+			// Get attributes
+			attrs := []string{fmt.Sprintf("label=%q", label)}
+			if e.Derived {
+				attrs = append(attrs, "style=dashed")
+			}
+			if e.Highlight {
+				attrs = append(attrs, "color=red", "penwidth=2")
+			}
+
+			fmt.Fprintf(&b, "\t%q -> %q [%s];\n", n.ID, e.To, strings.Join(attrs, ", "))
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package transformations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Test graph export with a highlighted path
+func TestExportGraph(t *testing.T) {
+
+	// Setup app state with a derived edge and an edge with two zones
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs3"},
+		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {{Type: CSTypeAffine, A10: 1, B01: 1, Invertible: true, Accuracy: 0.1}}},
+			"cs2": {"cs3": {{Border: squareBorder(0, 0, 10)}, {Type: CSTypeHelmert, Accuracy: 0.5}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypePlane, Name: "ptr", Direction: -1}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export CS graph
+	g, err := ExportGraph(GraphCS, "cs1", "cs3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check nodes and path
+	if len(g.Nodes) != 3 || g.Nodes[1] != (GraphNode{ID: "cs2", Highlight: true}) {
+		t.Errorf("unexpected nodes %+v", g.Nodes)
+	}
+	if expected := []string{"cs1", "cs2", "cs3"}; !reflect.DeepEqual(g.Path, expected) {
+		t.Errorf("expected path %v, received %v", expected, g.Path)
+	}
+
+	// Check edges
+	expected := map[string][]GraphEdge{
+		"cs1": {{To: "cs2", Types: []string{CSTypeAffine}, Zones: 1, Accuracy: 0.1, Highlight: true}},
+		"cs2": {
+			{To: "cs1", Types: []string{CSTypeAffine}, Zones: 1, Derived: true, Accuracy: 0.1},
+			{To: "cs3", Types: []string{CSTypePolynomial, CSTypeHelmert}, Zones: 2, Accuracy: 0.5, Highlight: true},
+		},
+	}
+	if !reflect.DeepEqual(g.Adjacency, expected) {
+		t.Errorf("expected edges %+v, received %+v", expected, g.Adjacency)
+	}
+
+	// Check DOT output
+	dot := g.DOT()
+	for _, line := range []string{
+		`"cs2" [shape=ellipse, style=dashed, color=red];`,
+		`"cs2" -> "cs1" [label="affine\n0.1 m", style=dashed];`,
+		`"cs2" -> "cs3" [label="polynomial/helmert x2\n0.5 m", color=red, penwidth=2];`,
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected %s in\n%s", line, dot)
+		}
+	}
+
+	// Export HS graph
+	g, err = ExportGraph(GraphHS, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := g.Adjacency["hs1"][0]; e.Name != "ptr" || e.Direction != -1 || e.Highlight {
+		t.Errorf("unexpected edge %+v", e)
+	}
+	if !strings.Contains(g.DOT(), `"hs1" -> "hs2" [label="plane ptr (-1)"];`) {
+		t.Errorf("unexpected DOT output\n%s", g.DOT())
+	}
+
+	// Reject unknown graph and unreachable systems
+	if _, err := ExportGraph("xs", "", ""); err == nil {
+		t.Error("expected error for unknown graph")
+	}
+	if _, err := ExportGraph(GraphHS, "hs2", "hs1"); err == nil {
+		t.Error("expected error for unreachable system")
+	}
+}
@@ -1,62 +1,145 @@
 package transformations
 
 import (
+	"math"
 	"slices"
+	"sort"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
 )
 
-// Generate distance graph
-func distGraph[T any](graph map[string]map[string]T, from string) map[string]int {
+// Tolerance for comparing path costs
+const costTolerance = 1e-9
 
-	// Create resulting graph
-	result := make(map[string]int)
+// Get cost of a graph edge
+// Cost is the squared stated accuracy, so routes with the smallest combined variance are preferred
+// Edges without a stated accuracy have the unstated cost of the graph
+func edgeCost(edge any, unstated float64) float64 {
 
-	// Track visited nodes
-	visited := map[string]bool{from: true}
+	// Get accuracy
+	accuracy := edgeAccuracy(edge)
 
-	// Track current distance
-	dist := 0
+	// Use unstated cost if not set
+	if accuracy <= 0 {
+		return unstated
+	}
 
-	// Track a list of nodes to visit next
-	nodes := []string{from}
+	return accuracy * accuracy
+}
 
-	// Infinate loop
-	for {
+// Get cost of graph edges without a stated accuracy
+// It is more than the combined cost of all stated edges, so routes with fewer unstated edges are always preferred,
+// and the most accurate one is chosen among them
+// On graphs without accuracies every edge costs 1, which gives hop counts
+func unstatedCost[T any](graph map[string]map[string]T) float64 {
+
+	// Sum stated costs
+	cost := 1.0
+	for _, connections := range graph {
+		for _, edge := range connections {
+			if accuracy := edgeAccuracy(edge); accuracy > 0 {
+				cost += accuracy * accuracy
+			}
+		}
+	}
+
+	return cost
+}
+
+// Get stated accuracy of a graph edge, zero if not set
+// CS edges use their least accurate zone
+func edgeAccuracy(edge any) float64 {
 
-		// Track next nodes
-		var nextNodes []string
+	// Get accuracy
+	accuracy := 0.0
+	switch e := edge.(type) {
+	case []config.CSTransformation:
+		for _, zone := range e {
+			accuracy = max(accuracy, zone.Accuracy)
+		}
 
-		// Iterate over nodes
-		for _, node := range nodes {
+	case config.HSTransformation:
+		accuracy = e.Accuracy
+	}
 
-			// Update node distance
-			result[node] = dist
+	return accuracy
+}
 
-			// Iterate node connections
-			for next := range graph[node] {
+// Get sorted node connections, for deterministic traversal
+func sortedConnections[T any](graph map[string]map[string]T, from string) []string {
 
-				// Skip if already visited
-				if visited[next] {
-					continue
-				}
+	// Get connections
+	res := make([]string, 0, len(graph[from]))
+	for node := range graph[from] {
+		res = append(res, node)
+	}
 
-				// Add next node
-				nextNodes = append(nextNodes, next)
+	// Sort
+	sort.Strings(res)
 
-				// Mark as visited
-				visited[next] = true
+	return res
+}
+
+// Reverse graph edges
+func reverseGraph[T any](graph map[string]map[string]T) map[string]map[string]T {
+
+	// Store result
+	res := make(map[string]map[string]T, len(graph))
+
+	// Iterate edges
+	for from, connections := range graph {
+		for to, edge := range connections {
+			if res[to] == nil {
+				res[to] = make(map[string]T)
 			}
+			res[to][from] = edge
 		}
+	}
 
-		// If no next nodes, exit
-		if len(nextNodes) == 0 {
-			break
+	return res
+}
+
+// Generate distance graph with Dijkstra's algorithm
+// Edges without a stated accuracy cost unstated, see unstatedCost
+func distGraph[T any](graph map[string]map[string]T, from string, unstated float64) map[string]float64 {
+
+	// Create resulting graph
+	result := map[string]float64{}
+
+	// Track tentative distances
+	tentative := map[string]float64{from: 0}
+
+	// Visit nodes in order of distance
+	for len(tentative) > 0 {
+
+		// Get closest node, breaking ties by name
+		node := ""
+		dist := math.Inf(1)
+		for n, d := range tentative {
+			if d < dist || (d == dist && n < node) {
+				node = n
+				dist = d
+			}
 		}
 
-		// Set next as current
-		nodes = nextNodes
+		// Mark as visited
+		delete(tentative, node)
+		result[node] = dist
+
+		// Iterate node connections
+		for next, edge := range graph[node] {
+
+			// Skip if already visited
+			if _, ok := result[next]; ok {
+				continue
+			}
 
-		// Update dist
-		dist++
+			// Update tentative distance
+			d := dist + edgeCost(edge, unstated)
+			if old, ok := tentative[next]; !ok || d < old {
+				tentative[next] = d
+			}
+		}
 	}
 
 	// Return
@@ -64,7 +147,9 @@ func distGraph[T any](graph map[string]map[string]T, from string) map[string]int
 }
 
 // Find path through distance graph
-func findPath[T any](graph map[string]map[string]T, dists map[string]int, from, to string, path []string) ([]string, bool) {
+// Follows edges on a shortest path, trying connections in name order
+// Edge costs must match the ones, used for the distance graph
+func findPath[T any](graph map[string]map[string]T, dists map[string]float64, unstated float64, from, to string, path []string) ([]string, bool) {
 
 	// If from is target, return
 	if from == to {
@@ -75,15 +160,16 @@ func findPath[T any](graph map[string]map[string]T, dists map[string]int, from,
 	dist := dists[from]
 
 	// Iterate from connections
-	for node := range graph[from] {
+	for _, node := range sortedConnections(graph, from) {
 
-		// Skip if distance is not larger
-		if dists[node] <= dist {
+		// Skip if edge is not on a shortest path
+		d, ok := dists[node]
+		if !ok || math.Abs(d-(dist+edgeCost(graph[from][node], unstated))) > costTolerance*max(1, d) {
 			continue
 		}
 
 		// Get path
-		newPath, found := findPath(graph, dists, node, to, append(path, node))
+		newPath, found := findPath(graph, dists, unstated, node, to, append(path, node))
 
 		// Return if found
 		if found {
@@ -98,14 +184,17 @@ func findPath[T any](graph map[string]map[string]T, dists map[string]int, from,
 // Find graph path, including up to two targets
 func findPathGraph[T any](graph map[string]map[string]T, from string, targets [2]string) (map[string][]string, bool) {
 
+	// Get cost of edges without accuracy, which is the same on the reversed graph
+	unstated := unstatedCost(graph)
+
 	// If only one target
 	if targets[1] == "" {
 
 		// Build dist graph
-		dg := distGraph(graph, from)
+		dg := distGraph(graph, from, unstated)
 
 		// Build path
-		path, found := findPath(graph, dg, from, targets[0], []string{})
+		path, found := findPath(graph, dg, unstated, from, targets[0], []string{})
 
 		// If not found
 		if !found {
@@ -133,43 +222,51 @@ func findPathGraph[T any](graph map[string]map[string]T, from string, targets [2
 	}
 
 	// If more than one target, find anchor point
-	combinedDist := make(map[string][]int)
-	var dg = [3]map[string]int{}
-
-	// Build distance graph for the three points
-	// We are assuming that all vertices in the graph are bi-directional
-	for i, node := range []string{from, targets[0], targets[1]} {
-
-		// Build dist graph
-		dg[i] = distGraph(graph, node)
-
-		// Iterate nodes
-		for dgNode, val := range dg[i] {
-
-			// Add value
-			combinedDist[dgNode] = append(combinedDist[dgNode], val)
-		}
+	// Distances to targets are found on the reversed graph
+	reversed := reverseGraph(graph)
+	dg := [3]map[string]float64{
+		distGraph(graph, from, unstated),
+		distGraph(reversed, targets[0], unstated),
+		distGraph(reversed, targets[1], unstated),
 	}
 
 	// Track min dist
-	minDist := 100000000
+	minDist := math.Inf(1)
+	minStartDist := math.Inf(1)
 	minDistNode := ""
 
-	// Iterate over dist nodes
-	for node, dists := range combinedDist {
+	// Iterate over nodes, reachable from start
+	for node, startDist := range dg[0] {
+
+		// Skip nodes, that can't reach both targets
+		d1, ok1 := dg[1][node]
+		d2, ok2 := dg[2][node]
+		if !ok1 || !ok2 {
+			continue
+		}
 
 		// Get dist
-		dist := dists[0] + dists[1] + dists[2]
+		dist := startDist + d1 + d2
 
-		// Update min
-		if dist < minDist {
+		// Update min, breaking ties by distance from start and by name
+		better := dist < minDist-costTolerance
+		if math.Abs(dist-minDist) <= costTolerance {
+			better = startDist < minStartDist || (startDist == minStartDist && node < minDistNode)
+		}
+		if better {
 			minDist = dist
+			minStartDist = startDist
 			minDistNode = node
 		}
 	}
 
+	// Check if targets can be reached
+	if minDistNode == "" {
+		return nil, false
+	}
+
 	// Find path from start, to minDistNode
-	path, found := findPath(graph, dg[0], from, minDistNode, []string{})
+	path, found := findPath(graph, dg[0], unstated, from, minDistNode, []string{})
 	if !found {
 		return nil, false
 	}
@@ -190,14 +287,19 @@ func findPathGraph[T any](graph map[string]map[string]T, from string, targets [2
 		cn = node
 	}
 
-	// Find path from target 1, to minDistNode
-	path, found = findPath(graph, dg[1], targets[0], minDistNode, []string{})
-	if !found {
-		return nil, false
-	}
+	// Add paths from minDistNode to targets
+	for i, target := range targets {
+
+		// Find path from target, to minDistNode on the reversed graph
+		path, found = findPath(reversed, dg[i+1], unstated, target, minDistNode, []string{})
+		if !found {
+			return nil, false
+		}
 
-	// If there is a path
-	if len(path) > 0 {
+		// Skip if there is no path
+		if len(path) == 0 {
+			continue
+		}
 
 		// Reverse path
 		slices.Reverse(path)
@@ -208,7 +310,7 @@ func findPathGraph[T any](graph map[string]map[string]T, from string, targets [2
 
 		// Iterate path
 		// Remove first node (minDistNode) and add the target to the end
-		for _, node := range append(path[1:], targets[0]) {
+		for _, node := range append(path[1:], target) {
 
 			// Add link
 			res[cn] = append(res[cn], node)
@@ -218,33 +320,47 @@ func findPathGraph[T any](graph map[string]map[string]T, from string, targets [2
 		}
 	}
 
-	// Find path from target 1, to minDistNode
-	path, found = findPath(graph, dg[2], targets[1], minDistNode, []string{})
-	if !found {
-		return nil, false
-	}
+	return res, true
+}
 
-	// If there is a path
-	if len(path) > 0 {
+// Convert a linear path graph to an ordered list of nodes
+func walkPath(path map[string][]string, from string) []string {
 
-		// Reverse path
-		slices.Reverse(path)
+	// Store result
+	res := []string{from}
 
-		// Convert path to graph
-		// Get start
-		cn = minDistNode
-
-		// Iterate path
-		// Remove first node (minDistNode) and add the target to the end
-		for _, node := range append(path[1:], targets[1]) {
+	// Traverse graph
+	for {
 
-			// Add link
-			res[cn] = append(res[cn], node)
+		// Get next node
+		to, ok := path[from]
 
-			// Update current node
-			cn = node
+		// Exit if not found
+		if !ok || len(to) == 0 {
+			break
 		}
+
+		// Add node
+		res = append(res, to[0])
+
+		// Update from
+		from = to[0]
 	}
 
-	return res, true
+	return res
+}
+
+// Get hops of a linear path graph
+func pathHops(path map[string][]string, from string) [][2]string {
+
+	// Get nodes
+	nodes := walkPath(path, from)
+
+	// Build hops
+	res := make([][2]string, 0, len(nodes))
+	for i := 1; i < len(nodes); i++ {
+		res = append(res, [2]string{nodes[i-1], nodes[i]})
+	}
+
+	return res
 }
@@ -1,6 +1,7 @@
 package transformations
 
 import (
+	"math"
 	"reflect"
 	"sort"
 	"strings"
@@ -25,14 +26,11 @@ var mockPathState config.App = config.App{
 		"cs1": {
 			"cs2": []config.CSTransformation{
 				{
-					Border: []struct {
-						X float64 `yaml:"X"`
-						Y float64 `yaml:"Y"`
-					}{
-						{0, 0},
-						{0, 10},
-						{10, 10},
-						{10, 0},
+					Border: config.Ring{
+						{X: 0, Y: 0},
+						{X: 0, Y: 10},
+						{X: 10, Y: 10},
+						{X: 10, Y: 0},
 					},
 					X0: 10,
 					Y0: 10,
@@ -42,14 +40,11 @@ var mockPathState config.App = config.App{
 		"cs2": {
 			"cs1": []config.CSTransformation{
 				{
-					Border: []struct {
-						X float64 `yaml:"X"`
-						Y float64 `yaml:"Y"`
-					}{
-						{10, 10},
-						{10, 20},
-						{20, 20},
-						{20, 10},
+					Border: config.Ring{
+						{X: 10, Y: 10},
+						{X: 10, Y: 20},
+						{X: 20, Y: 20},
+						{X: 20, Y: 10},
 					},
 					X0: -10,
 					Y0: -10,
@@ -175,17 +170,20 @@ var defaultGrid = config.HGridTransformation{
 	GridSize: 100,
 }
 
-func expect(t *testing.T, from, to string, val, res int) {
+func expect(t *testing.T, from, to string, val, res float64) {
 	if val != res {
-		t.Errorf("Expected %d, received %d on dist from %s to %s", res, val, from, to)
+		t.Errorf("Expected %g, received %g on dist from %s to %s", res, val, from, to)
 	}
 }
 
 // Test distance builder
 func TestDist(t *testing.T) {
 
+	// Get cost of edges without accuracy
+	unstated := unstatedCost(mockPathState.HsGraph)
+
 	// Generate dist map
-	res := distGraph(mockPathState.HsGraph, "hs1")
+	res := distGraph(mockPathState.HsGraph, "hs1", unstated)
 
 	// Test
 	expect(t, "hs1", "hs1", res["hs1"], 0)
@@ -197,7 +195,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs1", "hs5", res["hs5"], 4)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs2")
+	res = distGraph(mockPathState.HsGraph, "hs2", unstated)
 
 	// Test
 	expect(t, "hs2", "hs1", res["hs1"], 1)
@@ -208,7 +206,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs2", "hs5", res["hs5"], 3)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs3")
+	res = distGraph(mockPathState.HsGraph, "hs3", unstated)
 
 	// Test
 	expect(t, "hs3", "hs2", res["hs2"], 1)
@@ -219,7 +217,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs3", "hs5", res["hs5"], 3)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs4")
+	res = distGraph(mockPathState.HsGraph, "hs4", unstated)
 
 	// Test
 	expect(t, "hs4", "hs2", res["hs2"], 1)
@@ -230,7 +228,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs4", "hs6", res["hs6"], 2)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs5")
+	res = distGraph(mockPathState.HsGraph, "hs5", unstated)
 
 	// Test
 	expect(t, "hs5", "hs7", res["hs7"], 1)
@@ -241,7 +239,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs5", "hs1", res["hs1"], 4)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs6")
+	res = distGraph(mockPathState.HsGraph, "hs6", unstated)
 
 	// Test
 	expect(t, "hs6", "hs3", res["hs3"], 1)
@@ -252,7 +250,7 @@ func TestDist(t *testing.T) {
 	expect(t, "hs6", "hs1", res["hs1"], 3)
 
 	// Generate dist map
-	res = distGraph(mockPathState.HsGraph, "hs7")
+	res = distGraph(mockPathState.HsGraph, "hs7", unstated)
 
 	// Test
 	expect(t, "hs7", "hs4", res["hs4"], 1)
@@ -370,14 +368,17 @@ func TestPath(t *testing.T) {
 		},
 	}
 
+	// Get cost of edges without accuracy
+	unstated := unstatedCost(mockPathState.HsGraph)
+
 	// Run cases
 	for _, c := range cases {
 
 		// Get dist graph
-		dg := distGraph(mockPathState.HsGraph, c.start)
+		dg := distGraph(mockPathState.HsGraph, c.start, unstated)
 
 		// Run case
-		res, found := findPath(mockPathState.HsGraph, dg, c.start, c.target, []string{})
+		res, found := findPath(mockPathState.HsGraph, dg, unstated, c.start, c.target, []string{})
 		if !found {
 			t.Errorf("Path not found from %s to %s", c.start, c.target)
 			return
@@ -573,3 +574,65 @@ func TestPathGraph(t *testing.T) {
 		}
 	}
 }
+
+// Test path selection by accuracy
+func TestWeightedPath(t *testing.T) {
+
+	// Create graph with an accurate long route, an inaccurate short route and two equal routes
+	edge := func(accuracy float64) config.HSTransformation {
+		return config.HSTransformation{Type: "plane", Accuracy: accuracy}
+	}
+	graph := map[string]map[string]config.HSTransformation{
+		"a": {"b": edge(0.5), "c": edge(0.1)},
+		"b": {"d": edge(0.5)},
+		"c": {"e": edge(0.1)},
+		"e": {"d": edge(0.1)},
+		"d": {"y": edge(0), "x": edge(0)},
+		"x": {"z": edge(0)},
+		"y": {"z": edge(0)},
+	}
+
+	// Accurate route should be preferred over fewer hops
+	res, found := findPathGraph(graph, "a", [2]string{"d"})
+	if expected := map[string][]string{"a": {"c"}, "c": {"e"}, "e": {"d"}}; !found || !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v; Received %v", expected, res)
+	}
+
+	// Equal routes should be chosen by name
+	for i := 0; i < 20; i++ {
+		res, found = findPathGraph(graph, "d", [2]string{"z"})
+		if expected := map[string][]string{"d": {"x"}, "x": {"z"}}; !found || !reflect.DeepEqual(res, expected) {
+			t.Fatalf("Expected %v; Received %v", expected, res)
+		}
+	}
+
+	// Targets reachable only in one direction
+	res, found = findPathGraph(graph, "a", [2]string{"e", "z"})
+	if expected := map[string][]string{"a": {"c"}, "c": {"e"}, "e": {"d"}, "d": {"x"}, "x": {"z"}}; !found || !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v; Received %v", expected, res)
+	}
+	// Routes with stated accuracies should be preferred over unstated edges, however inaccurate they are
+	mixed := map[string]map[string]config.HSTransformation{
+		"a": {"b": edge(0), "c": edge(2)},
+		"b": {"d": edge(0)},
+		"c": {"d": edge(3)},
+		"d": {"e": edge(0), "f": edge(0.1)},
+		"e": {"g": edge(0)},
+		"f": {"g": edge(0)},
+	}
+	res, found = findPathGraph(mixed, "a", [2]string{"d"})
+	if expected := map[string][]string{"a": {"c"}, "c": {"d"}}; !found || !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v; Received %v", expected, res)
+	}
+
+	// With the same number of unstated edges, stated accuracies decide
+	res, found = findPathGraph(mixed, "d", [2]string{"g"})
+	if expected := map[string][]string{"d": {"f"}, "f": {"g"}}; !found || !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v; Received %v", expected, res)
+	}
+
+	// Unstated edges cost more than all stated edges together
+	if cost := unstatedCost(mixed); math.Abs(cost-14.01) > 1e-9 {
+		t.Errorf("Expected unstated cost 14.01; Received %g", cost)
+	}
+}
@@ -0,0 +1,161 @@
+package transformations
+
+import (
+	"errors"
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Default inverse solution parameters
+const (
+	defaultInverseTolerance     = 1e-4
+	defaultInverseMaxIterations = 20
+)
+
+// Inverse solution did not converge
+var errNotConverged = errors.New("inverse transformation did not converge")
+
+// Evaluate 3rd order bivariate polynomial
+func applyPolynomial(zone config.CSTransformation, x, y float64) (float64, float64) {
+
+	// Helper values
+	dx := x - zone.X0
+	dy := y - zone.Y0
+
+	// Transform point
+	rx := zone.A00 +
+		zone.A10*dx +
+		zone.A01*dy +
+		zone.A20*dx*dx +
+		zone.A11*dx*dy +
+		zone.A02*dy*dy +
+		zone.A30*dx*dx*dx +
+		zone.A21*dx*dx*dy +
+		zone.A12*dx*dy*dy +
+		zone.A03*dy*dy*dy
+	ry := zone.B00 +
+		zone.B10*dx +
+		zone.B01*dy +
+		zone.B20*dx*dx +
+		zone.B11*dx*dy +
+		zone.B02*dy*dy +
+		zone.B30*dx*dx*dx +
+		zone.B21*dx*dx*dy +
+		zone.B12*dx*dy*dy +
+		zone.B03*dy*dy*dy
+
+	return rx, ry
+}
+
+// Get configured tolerance and iteration limit for numerical inverses
+func inverseSettings() (float64, int) {
+	tolerance := Repo.App.InverseTolerance
+	if tolerance <= 0 {
+		tolerance = defaultInverseTolerance
+	}
+	maxIterations := Repo.App.InverseMaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultInverseMaxIterations
+	}
+	return tolerance, maxIterations
+}
+
+// Solve the polynomial for the source point with Newton's method
+// Returns the source point and the residual in target units
+func invertPolynomial(zone config.CSTransformation, x, y float64) (float64, float64, float64, error) {
+
+	// Get solution parameters
+	tolerance, maxIterations := inverseSettings()
+
+	// Get initial guess from the linear terms
+	det := zone.A10*zone.B01 - zone.A01*zone.B10
+	if det == 0 {
+		return x, y, math.Inf(1), errNotConverged
+	}
+	dx := ((x-zone.A00)*zone.B01 - (y-zone.B00)*zone.A01) / det
+	dy := ((y-zone.B00)*zone.A10 - (x-zone.A00)*zone.B10) / det
+
+	// Track residual
+	residual := math.Inf(1)
+
+	// Iterate
+	for i := 0; i < maxIterations; i++ {
+
+		// Get error of current solution
+		fx, fy := applyPolynomial(zone, zone.X0+dx, zone.Y0+dy)
+		fx -= x
+		fy -= y
+
+		// Exit if converged
+		residual = math.Hypot(fx, fy)
+		if residual <= tolerance {
+			return zone.X0 + dx, zone.Y0 + dy, residual, nil
+		}
+
+		// Get jacobian
+		ax := zone.A10 + 2*zone.A20*dx + zone.A11*dy + 3*zone.A30*dx*dx + 2*zone.A21*dx*dy + zone.A12*dy*dy
+		ay := zone.A01 + zone.A11*dx + 2*zone.A02*dy + zone.A21*dx*dx + 2*zone.A12*dx*dy + 3*zone.A03*dy*dy
+		bx := zone.B10 + 2*zone.B20*dx + zone.B11*dy + 3*zone.B30*dx*dx + 2*zone.B21*dx*dy + zone.B12*dy*dy
+		by := zone.B01 + zone.B11*dx + 2*zone.B02*dy + zone.B21*dx*dx + 2*zone.B12*dx*dy + 3*zone.B03*dy*dy
+
+		// Solve for step
+		det := ax*by - ay*bx
+		if det == 0 {
+			break
+		}
+		dx -= (fx*by - fy*ay) / det
+		dy -= (fy*ax - fx*bx) / det
+	}
+
+	return zone.X0 + dx, zone.Y0 + dy, residual, errNotConverged
+}
+
+// Add derived reverse hops for invertible zones
+// Hops, that are already defined are not replaced
+func deriveInverses(graph map[string]map[string][]config.CSTransformation) map[string]map[string][]config.CSTransformation {
+
+	// Copy graph
+	res := make(map[string]map[string][]config.CSTransformation, len(graph))
+	for from, edges := range graph {
+		res[from] = make(map[string][]config.CSTransformation, len(edges))
+		for to, zones := range edges {
+			res[from][to] = zones
+		}
+	}
+
+	// Iterate over hops
+	for from, edges := range graph {
+		for to, zones := range edges {
+
+			// Skip if reverse hop exists
+			if _, ok := graph[to][from]; ok {
+				continue
+			}
+
+			// Get invertible zones
+			var inverse []config.CSTransformation
+			for _, zone := range zones {
+				if zone.Invertible && !zone.Inverse {
+
+					// Conversions are derived from system kinds and have no inverse form
+					zone.Inverse = zoneType(zone) != CSTypeConversion
+					inverse = append(inverse, zone)
+				}
+			}
+
+			// Skip if nothing to invert
+			if len(inverse) == 0 {
+				continue
+			}
+
+			// Add reverse hop
+			if res[to] == nil {
+				res[to] = map[string][]config.CSTransformation{}
+			}
+			res[to][from] = inverse
+		}
+	}
+
+	return res
+}
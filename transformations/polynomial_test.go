@@ -0,0 +1,90 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Test numeric polynomial inverse
+func TestInvertPolynomial(t *testing.T) {
+
+	// Setup default solution parameters
+	Setup(&config.App{})
+
+	// Define zone with non linear terms
+	zone := config.CSTransformation{
+		X0:  4700000,
+		Y0:  400000,
+		A00: 4700123.45,
+		A10: 1.0002,
+		A01: -0.0003,
+		A20: 1e-9,
+		A11: -2e-9,
+		A30: 1e-15,
+		B00: 400321.12,
+		B10: 0.0004,
+		B01: 0.9998,
+		B02: 3e-9,
+		B21: -1e-15,
+	}
+
+	// Check points around the origin
+	for _, p := range [][2]float64{{4700000, 400000}, {4712345.6, 387654.3}, {4650000, 450000}} {
+
+		// Transform forward
+		fx, fy := applyPolynomial(zone, p[0], p[1])
+
+		// Transform back
+		x, y, residual, err := invertPolynomial(zone, fx, fy)
+		if err != nil {
+			t.Errorf("inverse of %v did not converge, residual %g", p, residual)
+			continue
+		}
+
+		// Check round trip
+		if math.Hypot(x-p[0], y-p[1]) > 1e-3 {
+			t.Errorf("round trip of %v returned %v, %v", p, x, y)
+		}
+	}
+
+	// Singular zone should not converge
+	if _, _, _, err := invertPolynomial(config.CSTransformation{}, 1, 1); err == nil {
+		t.Error("expected error for singular zone")
+	}
+}
+
+// Test derived reverse hops
+func TestDeriveInverses(t *testing.T) {
+
+	// Define graph
+	graph := map[string]map[string][]config.CSTransformation{
+		"cs1": {
+			"cs2": {{Name: "z1", Invertible: true}, {Name: "z2"}},
+			"cs3": {{Name: "z3", Invertible: true}},
+		},
+		"cs3": {
+			"cs1": {{Name: "z4"}},
+		},
+	}
+
+	// Derive inverses
+	res := deriveInverses(graph)
+
+	// Reverse hop should include invertible zones only
+	zones, ok := res["cs2"]["cs1"]
+	if !ok || len(zones) != 1 || zones[0].Name != "z1" || !zones[0].Inverse {
+		t.Errorf("expected derived cs2 to cs1 hop, received %v", zones)
+	}
+
+	// Defined hops should be kept
+	if zones := res["cs3"]["cs1"]; len(zones) != 1 || zones[0].Name != "z4" {
+		t.Errorf("expected defined cs3 to cs1 hop, received %v", zones)
+	}
+
+	// Source graph should not be changed
+	if _, ok := graph["cs2"]; ok {
+		t.Error("source graph was changed")
+	}
+}
@@ -0,0 +1,297 @@
+package transformations
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Projection methods
+const (
+	ProjectionLCC = "lcc"
+	ProjectionTM  = "tm"
+)
+
+// Degrees to radians
+const deg = math.Pi / 180
+
+// Projection with ellipsoid
+type projection struct {
+	config.Projection
+	e config.Ellipsoid
+}
+
+// Get coordinate system projection
+func csProjection(cs string) (projection, error) {
+
+	// Get projection name
+	name := Repo.App.CSDefinitions[cs].Projection
+
+	// Get projection
+	p, ok := Repo.App.Projections[name]
+	if !ok {
+		return projection{}, fmt.Errorf("unknown projection '%s' for %s", name, cs)
+	}
+
+	// Get ellipsoid
+	e, err := csEllipsoid(cs)
+	if err != nil {
+		return projection{}, err
+	}
+
+	return projection{Projection: p, e: e}, nil
+}
+
+// Get scale factor, defaulting to 1
+func (p projection) k0() float64 {
+	if p.K0 == 0 {
+		return 1
+	}
+	return p.K0
+}
+
+// Project geographic coordinates
+// Returns northing as X and easting as Y
+func (p projection) forward(lat, lon float64) (float64, float64, error) {
+	switch p.Method {
+	case ProjectionTM:
+		n, e := p.forwardTM(lat*deg, lon*deg)
+		return n, e, nil
+	case ProjectionLCC:
+		n, e := p.forwardLCC(lat*deg, lon*deg)
+		return n, e, nil
+	}
+	return lat, lon, fmt.Errorf("unsupported projection method %s", p.Method)
+}
+
+// Get geographic coordinates from projected
+// Takes northing as X and easting as Y
+func (p projection) inverse(n, e float64) (float64, float64, error) {
+	switch p.Method {
+	case ProjectionTM:
+		lat, lon := p.inverseTM(n, e)
+		return lat / deg, lon / deg, nil
+	case ProjectionLCC:
+		lat, lon := p.inverseLCC(n, e)
+		return lat / deg, lon / deg, nil
+	}
+	return n, e, fmt.Errorf("unsupported projection method %s", p.Method)
+}
+
+// Get ellipsoid eccentricity
+func (p projection) eccentricity() float64 {
+	return math.Sqrt(eccentricity2(p.e))
+}
+
+// Transverse Mercator series coefficients
+func (p projection) tmSeries() (float64, [4]float64, [4]float64) {
+
+	// Get third flattening
+	f := 1 / p.e.InvF
+	n := f / (2 - f)
+	n2, n3, n4 := n*n, n*n*n, n*n*n*n
+
+	// Get radius of rectifying sphere
+	b := p.e.A / (1 + n) * (1 + n2/4 + n4/64)
+
+	// Forward coefficients
+	h := [4]float64{
+		n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180,
+		13*n2/48 - 3*n3/5 + 557*n4/1440,
+		61*n3/240 - 103*n4/140,
+		49561 * n4 / 161280,
+	}
+
+	// Inverse coefficients
+	hi := [4]float64{
+		n/2 - 2*n2/3 + 37*n3/96 - n4/360,
+		n2/48 + n3/15 - 437*n4/1440,
+		17*n3/480 - 37*n4/840,
+		4397 * n4 / 161280,
+	}
+
+	return b, h, hi
+}
+
+// Get conformal latitude
+func (p projection) conformalLatitude(phi float64) float64 {
+	e := p.eccentricity()
+	q := math.Asinh(math.Tan(phi)) - e*math.Atanh(e*math.Sin(phi))
+	return math.Atan(math.Sinh(q))
+}
+
+// Get meridian distance of latitude of origin
+func (p projection) tmOrigin(b float64, h [4]float64) float64 {
+
+	// Origin on equator
+	if p.Lat0 == 0 {
+		return 0
+	}
+
+	// Get rectifying latitude
+	xi0 := math.Asin(math.Sin(p.conformalLatitude(p.Lat0 * deg)))
+	xi := xi0
+	for i, hi := range h {
+		xi += hi * math.Sin(float64(2*(i+1))*xi0)
+	}
+
+	return b * xi
+}
+
+// Transverse Mercator forward projection, using Krüger series
+func (p projection) forwardTM(phi, lambda float64) (float64, float64) {
+
+	// Get series
+	b, h, _ := p.tmSeries()
+	k0 := p.k0()
+
+	// Get gaussian coordinates
+	beta := p.conformalLatitude(phi)
+	eta0 := math.Atanh(math.Cos(beta) * math.Sin(lambda-p.Lon0*deg))
+	xi0 := math.Asin(math.Sin(beta) * math.Cosh(eta0))
+
+	// Apply series
+	xi, eta := xi0, eta0
+	for i, hi := range h {
+		k := float64(2 * (i + 1))
+		xi += hi * math.Sin(k*xi0) * math.Cosh(k*eta0)
+		eta += hi * math.Cos(k*xi0) * math.Sinh(k*eta0)
+	}
+
+	// Get projected coordinates
+	e := p.FalseEasting + k0*b*eta
+	n := p.FalseNorthing + k0*(b*xi-p.tmOrigin(b, h))
+
+	return n, e
+}
+
+// Transverse Mercator inverse projection, using Krüger series
+func (p projection) inverseTM(n, e float64) (float64, float64) {
+
+	// Get series
+	b, h, hi := p.tmSeries()
+	k0 := p.k0()
+
+	// Get normalized coordinates
+	eta := (e - p.FalseEasting) / (b * k0)
+	xi := ((n - p.FalseNorthing) + k0*p.tmOrigin(b, h)) / (b * k0)
+
+	// Apply series
+	xi0, eta0 := xi, eta
+	for i, c := range hi {
+		k := float64(2 * (i + 1))
+		xi0 -= c * math.Sin(k*xi) * math.Cosh(k*eta)
+		eta0 -= c * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+
+	// Get conformal latitude
+	beta := math.Asin(math.Sin(xi0) / math.Cosh(eta0))
+
+	// Get latitude iteratively
+	ecc := p.eccentricity()
+	q := math.Asinh(math.Tan(beta))
+	qi := q
+	for i := 0; i < 20; i++ {
+		next := q + ecc*math.Atanh(ecc*math.Tanh(qi))
+		if math.Abs(next-qi) < 1e-14 {
+			qi = next
+			break
+		}
+		qi = next
+	}
+	phi := math.Atan(math.Sinh(qi))
+	lambda := p.Lon0*deg + math.Asin(math.Tanh(eta0)/math.Cos(beta))
+
+	return phi, lambda
+}
+
+// Lambert Conformal Conic helper m
+func (p projection) lccM(phi float64) float64 {
+	e2 := eccentricity2(p.e)
+	return math.Cos(phi) / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+}
+
+// Lambert Conformal Conic helper t
+func (p projection) lccT(phi float64) float64 {
+	e := p.eccentricity()
+	return math.Tan(math.Pi/4-phi/2) / math.Pow((1-e*math.Sin(phi))/(1+e*math.Sin(phi)), e/2)
+}
+
+// Lambert Conformal Conic cone constants
+// Uses two standard parallels if they are set, or the scale factor at latitude of origin
+func (p projection) lccCone() (float64, float64, float64) {
+
+	// Get origin
+	phi0 := p.Lat0 * deg
+	t0 := p.lccT(phi0)
+
+	// One standard parallel
+	if p.Lat1 == 0 && p.Lat2 == 0 {
+		n := math.Sin(phi0)
+		f := p.lccM(phi0) / (n * math.Pow(t0, n))
+		r0 := p.e.A * f * math.Pow(t0, n) * p.k0()
+		return n, f * p.k0(), r0
+	}
+
+	// Two standard parallels
+	phi1, phi2 := p.Lat1*deg, p.Lat2*deg
+	m1, m2 := p.lccM(phi1), p.lccM(phi2)
+	t1, t2 := p.lccT(phi1), p.lccT(phi2)
+	var n float64
+	if phi1 == phi2 {
+		n = math.Sin(phi1)
+	} else {
+		n = (math.Log(m1) - math.Log(m2)) / (math.Log(t1) - math.Log(t2))
+	}
+	f := m1 / (n * math.Pow(t1, n))
+	r0 := p.e.A * f * math.Pow(t0, n)
+
+	return n, f, r0
+}
+
+// Lambert Conformal Conic forward projection
+func (p projection) forwardLCC(phi, lambda float64) (float64, float64) {
+
+	// Get cone
+	n, f, r0 := p.lccCone()
+
+	// Get polar coordinates
+	r := p.e.A * f * math.Pow(p.lccT(phi), n)
+	theta := n * (lambda - p.Lon0*deg)
+
+	// Get projected coordinates
+	e := p.FalseEasting + r*math.Sin(theta)
+	north := p.FalseNorthing + r0 - r*math.Cos(theta)
+
+	return north, e
+}
+
+// Lambert Conformal Conic inverse projection
+func (p projection) inverseLCC(north, e float64) (float64, float64) {
+
+	// Get cone
+	n, f, r0 := p.lccCone()
+
+	// Get polar coordinates
+	de := e - p.FalseEasting
+	dn := r0 - (north - p.FalseNorthing)
+	r := math.Copysign(math.Hypot(de, dn), n)
+	theta := math.Atan2(math.Copysign(1, n)*de, math.Copysign(1, n)*dn)
+	t := math.Pow(r/(p.e.A*f), 1/n)
+
+	// Get latitude iteratively
+	ecc := p.eccentricity()
+	phi := math.Pi/2 - 2*math.Atan(t)
+	for i := 0; i < 20; i++ {
+		next := math.Pi/2 - 2*math.Atan(t*math.Pow((1-ecc*math.Sin(phi))/(1+ecc*math.Sin(phi)), ecc/2))
+		if math.Abs(next-phi) < 1e-14 {
+			phi = next
+			break
+		}
+		phi = next
+	}
+	lambda := theta/n + p.Lon0*deg
+
+	return phi, lambda
+}
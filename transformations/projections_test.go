@@ -0,0 +1,92 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Check projection forward and inverse against expected values
+func checkProjection(t *testing.T, name string, p projection, lat, lon, n, e float64) {
+
+	// Project
+	rn, re, err := p.forward(lat, lon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(rn-n) > 0.01 || math.Abs(re-e) > 0.01 {
+		t.Errorf("%s: expected N %.3f, E %.3f; received N %.3f, E %.3f", name, n, e, rn, re)
+	}
+
+	// Inverse of projected point
+	rlat, rlon, err := p.inverse(rn, re)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(rlat-lat) > 1e-9 || math.Abs(rlon-lon) > 1e-9 {
+		t.Errorf("%s: expected %.9f, %.9f; received %.9f, %.9f", name, lat, lon, rlat, rlon)
+	}
+}
+
+// Test projections against the EPSG guidance note examples
+func TestProjections(t *testing.T) {
+
+	// US survey foot
+	ft := 1200.0 / 3937
+
+	// Transverse Mercator, British National Grid
+	checkProjection(t, "tm", projection{
+		Projection: config.Projection{
+			Method:        ProjectionTM,
+			Lat0:          49,
+			Lon0:          -2,
+			K0:            0.9996012717,
+			FalseEasting:  400000,
+			FalseNorthing: -100000,
+		},
+		e: config.Ellipsoid{A: 6377563.396, InvF: 299.3249646},
+	}, 50.5, 0.5, 69740.50, 577274.99)
+
+	// Lambert Conic Conformal 2SP, Texas South Central
+	checkProjection(t, "lcc 2sp", projection{
+		Projection: config.Projection{
+			Method:       ProjectionLCC,
+			Lat0:         27 + 50/60.0,
+			Lon0:         -99,
+			Lat1:         28 + 23/60.0,
+			Lat2:         30 + 17/60.0,
+			FalseEasting: 2000000 * ft,
+		},
+		e: config.Ellipsoid{A: 6378206.4, InvF: 294.9786982},
+	}, 28.5, -96, 254759.80*ft, 2963503.91*ft)
+
+	// Lambert Conic Conformal 1SP, Jamaica
+	checkProjection(t, "lcc 1sp", projection{
+		Projection: config.Projection{
+			Method:        ProjectionLCC,
+			Lat0:          18,
+			Lon0:          -77,
+			K0:            1,
+			FalseEasting:  250000,
+			FalseNorthing: 150000,
+		},
+		e: config.Ellipsoid{A: 6378206.4, InvF: 294.9786982},
+	}, 17+55/60.0+55.8/3600, -(76 + 56/60.0 + 37.26/3600), 142493.51, 255966.58)
+
+	// UTM zone 35N, GRS80
+	utm := projection{
+		Projection: config.Projection{
+			Method:       ProjectionTM,
+			Lon0:         27,
+			K0:           0.9996,
+			FalseEasting: 500000,
+		},
+		e: defaultEllipsoids["GRS80"],
+	}
+	n, e, _ := utm.forward(42.7, 23.3)
+	lat, lon, _ := utm.inverse(n, e)
+	if math.Abs(lat-42.7) > 1e-9 || math.Abs(lon-23.3) > 1e-9 {
+		t.Errorf("utm: round trip returned %.9f, %.9f", lat, lon)
+	}
+}
@@ -0,0 +1,103 @@
+package transformations
+
+import (
+	"sort"
+	"strings"
+)
+
+// Coordinate system info
+type CSInfo struct {
+	ID          string   `json:"id"`
+	Family      string   `json:"family"`
+	Variant     string   `json:"variant"`
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Reachable   []string `json:"reachable"`
+}
+
+// Height system info
+type HSInfo struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Reachable   []string `json:"reachable"`
+}
+
+// List valid coordinate systems
+func ListCSs() []CSInfo {
+
+	// Store result
+	res := make([]CSInfo, 0, len(Repo.App.ValidCSs))
+
+	// Iterate over valid systems
+	for _, cs := range Repo.App.ValidCSs {
+
+		// Split id to family and variant
+		family, variant, _ := strings.Cut(cs, "-")
+
+		// Get description
+		desc := Repo.App.CSDescriptions[cs]
+
+		// Add system
+		res = append(res, CSInfo{
+			ID:          cs,
+			Family:      family,
+			Variant:     variant,
+			Kind:        CSKind(cs),
+			Name:        desc.Name,
+			Description: desc.Description,
+			Reachable:   reachable(Repo.CSGraph.data, cs, Repo.ValidCSs),
+		})
+	}
+
+	return res
+}
+
+// List valid height systems
+func ListHSs() []HSInfo {
+
+	// Store result
+	res := make([]HSInfo, 0, len(Repo.App.ValidHSs))
+
+	// Iterate over valid systems
+	for _, hs := range Repo.App.ValidHSs {
+
+		// Get description
+		desc := Repo.App.HSDescriptions[hs]
+
+		// Add system
+		res = append(res, HSInfo{
+			ID:          hs,
+			Name:        desc.Name,
+			Description: desc.Description,
+			Reachable:   reachable(Repo.HSGraph.data, hs, Repo.ValidHSs),
+		})
+	}
+
+	return res
+}
+
+// Get a sorted list of valid systems, that can be reached from a system
+func reachable[T any](graph map[string]map[string]T, from string, valid map[string]bool) []string {
+
+	// Store result
+	res := []string{}
+
+	// Iterate over distance graph, where only reachable nodes matter, so all edges cost 1
+	for node := range distGraph(graph, from, 1) {
+
+		// Skip start and systems, that are not exposed
+		if node == from || !valid[node] {
+			continue
+		}
+
+		// Add node
+		res = append(res, node)
+	}
+
+	// Sort for stable output
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,59 @@
+package transformations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Test system discovery
+func TestListSystems(t *testing.T) {
+
+	// Setup app state with a hidden intermediate system and a one-directional edge
+	app := config.App{
+		ValidCSs: []string{"bgs-2005", "wgs-84", "local-a"},
+		ValidHSs: []string{"hs1", "hs2", "hs3"},
+		CSDescriptions: map[string]config.SystemDescription{
+			"bgs-2005": {Name: "BGS 2005", Description: "cadastral"},
+		},
+		HSDescriptions: map[string]config.SystemDescription{
+			"hs2": {Name: "Baltic"},
+		},
+		CSDefinitions: map[string]config.CSDefinition{
+			"wgs-84": {Kind: KindGeographic},
+		},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"bgs-2005": {"hidden": {{Type: CSTypeAffine, A10: 1, B01: 1, Invertible: true}}},
+			"hidden":   {"wgs-84": {{Type: CSTypeAffine, A10: 1, B01: 1, Invertible: true}}},
+			"local-a":  {"bgs-2005": {{Type: CSTypeAffine, A10: 1, B01: 1}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypePlane, Name: "p", Direction: 1}},
+			"hs2": {"hs1": {Type: HSTypePlane, Name: "p", Direction: -1}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check coordinate systems
+	expected := []CSInfo{
+		{ID: "bgs-2005", Family: "bgs", Variant: "2005", Kind: KindProjected, Name: "BGS 2005", Description: "cadastral", Reachable: []string{"wgs-84"}},
+		{ID: "wgs-84", Family: "wgs", Variant: "84", Kind: KindGeographic, Reachable: []string{"bgs-2005"}},
+		{ID: "local-a", Family: "local", Variant: "a", Kind: KindProjected, Reachable: []string{"bgs-2005", "wgs-84"}},
+	}
+	if css := ListCSs(); !reflect.DeepEqual(css, expected) {
+		t.Errorf("expected %+v, received %+v", expected, css)
+	}
+
+	// Check height systems, unconnected systems reach nothing
+	expectedHS := []HSInfo{
+		{ID: "hs1", Reachable: []string{"hs2"}},
+		{ID: "hs2", Name: "Baltic", Reachable: []string{"hs1"}},
+		{ID: "hs3", Reachable: []string{}},
+	}
+	if hss := ListHSs(); !reflect.DeepEqual(hss, expectedHS) {
+		t.Errorf("expected %+v, received %+v", expectedHS, hss)
+	}
+}
@@ -0,0 +1,330 @@
+package transformations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// CS transformation methods
+const (
+	CSTypePolynomial = "polynomial"
+	CSTypeAffine     = "affine"
+	CSTypeConformal  = "conformal"
+	CSTypeHelmert    = "helmert"
+	CSTypeNTv2       = "ntv2"
+	CSTypeConversion = "conversion"
+)
+
+// Helmert rotation conventions
+const (
+	PositionVector  = "position-vector"
+	CoordinateFrame = "coordinate-frame"
+)
+
+// Arc seconds to radians
+const arcSecond = math.Pi / (180 * 3600)
+
+// Get zone method, defaulting to polynomial
+func zoneType(zone config.CSTransformation) string {
+	if zone.Type == "" {
+		return CSTypePolynomial
+	}
+	return zone.Type
+}
+
+// Check if point is in zone
+// Zones of parametric methods without a border are valid everywhere
+func zoneContains(zone config.CSTransformation, x, y float64) bool {
+	if zoneType(zone) != CSTypePolynomial && len(zone.Border) == 0 {
+		return true
+	}
+	return zone.InZone(x, y, borderTolerance())
+}
+
+// Default border tolerance
+const defaultBorderTolerance = 1e-9
+
+// Get border tolerance
+func borderTolerance() float64 {
+	if Repo.App == nil || Repo.App.BorderTolerance <= 0 {
+		return defaultBorderTolerance
+	}
+	return Repo.App.BorderTolerance
+}
+
+// Transform point with a single zone
+// Returns false if the point is not in the zone
+func applyZone(from, to string, zone config.CSTransformation, x, y, z float64) (float64, float64, float64, float64, bool, error) {
+
+	// Handle conversions between system kinds, which are valid everywhere
+	if zoneType(zone) == CSTypeConversion {
+		rx, ry, rz, err := convert(from, to, x, y, z)
+		return rx, ry, rz, 0, err == nil, err
+	}
+
+	// Handle derived inverse zones
+	// Zone border is defined in the forward source system, so it is checked after solving
+	if zone.Inverse {
+
+		// Solve
+		rx, ry, rz, residual, err := inverseZone(zone, x, y, z)
+		if errors.Is(err, grids.ErrOutOfBounds) {
+			return x, y, z, residual, false, nil
+		}
+		if err != nil {
+			return x, y, z, residual, false, err
+		}
+
+		// Check if solution is in zone
+		if !zoneContains(zone, rx, ry) {
+			return x, y, z, residual, false, nil
+		}
+
+		return rx, ry, rz, residual, true, nil
+	}
+
+	// Check if point is in zone
+	if !zoneContains(zone, x, y) {
+		return x, y, z, 0, false, nil
+	}
+
+	// Transform point
+	rx, ry, rz, err := forwardZone(zone, x, y, z)
+	if errors.Is(err, grids.ErrOutOfBounds) {
+		return x, y, z, 0, false, nil
+	}
+	if err != nil {
+		return x, y, z, 0, false, err
+	}
+
+	return rx, ry, rz, 0, true, nil
+}
+
+// Transform point with zone method
+func forwardZone(zone config.CSTransformation, x, y, z float64) (float64, float64, float64, error) {
+	switch zoneType(zone) {
+	case CSTypePolynomial:
+		rx, ry := applyPolynomial(zone, x, y)
+		return rx, ry, z, nil
+	case CSTypeAffine:
+		rx, ry := applyPolynomial(affineZone(zone), x, y)
+		return rx, ry, z, nil
+	case CSTypeConformal:
+		rx, ry := applyPolynomial(conformalZone(zone), x, y)
+		return rx, ry, z, nil
+	case CSTypeHelmert:
+		rx, ry, rz := applyHelmert(zone, x, y, z)
+		return rx, ry, rz, nil
+	case CSTypeNTv2:
+		rx, ry, err := applyNTv2(zone, x, y)
+		return rx, ry, z, err
+	}
+	return x, y, z, fmt.Errorf("unsupported CS transformation type %s", zone.Type)
+}
+
+// Transform point with the inverse of zone method
+func inverseZone(zone config.CSTransformation, x, y, z float64) (float64, float64, float64, float64, error) {
+	switch zoneType(zone) {
+	case CSTypePolynomial:
+		rx, ry, residual, err := invertPolynomial(zone, x, y)
+		return rx, ry, z, residual, err
+	case CSTypeAffine:
+		rx, ry, residual, err := invertPolynomial(affineZone(zone), x, y)
+		return rx, ry, z, residual, err
+	case CSTypeConformal:
+		rx, ry, residual, err := invertPolynomial(conformalZone(zone), x, y)
+		return rx, ry, z, residual, err
+	case CSTypeHelmert:
+		return invertHelmert(zone, x, y, z)
+	case CSTypeNTv2:
+		rx, ry, residual, err := invertNTv2(zone, x, y)
+		return rx, ry, z, residual, err
+	}
+	return x, y, z, 0, fmt.Errorf("unsupported CS transformation type %s", zone.Type)
+}
+
+// Get affine transformation as polynomial with first order terms only
+func affineZone(zone config.CSTransformation) config.CSTransformation {
+	return config.CSTransformation{
+		X0:  zone.X0,
+		Y0:  zone.Y0,
+		A00: zone.A00,
+		A10: zone.A10,
+		A01: zone.A01,
+		B00: zone.B00,
+		B10: zone.B10,
+		B01: zone.B01,
+	}
+}
+
+// Get 2D conformal transformation as polynomial with first order terms only
+// Uses translations Tx, Ty, rotation Rz and scale Ds
+func conformalZone(zone config.CSTransformation) config.CSTransformation {
+
+	// Get scaled rotation
+	m := 1 + zone.Ds*1e-6
+	r := zone.Rz * arcSecond
+	a := m * math.Cos(r)
+	b := m * math.Sin(r)
+
+	return config.CSTransformation{
+		X0:  zone.X0,
+		Y0:  zone.Y0,
+		A00: zone.Tx,
+		A10: a,
+		A01: -b,
+		B00: zone.Ty,
+		B10: b,
+		B01: a,
+	}
+}
+
+// Get helmert rotation matrix, including scale
+func helmertMatrix(zone config.CSTransformation) [3][3]float64 {
+
+	// Get parameters
+	m := 1 + zone.Ds*1e-6
+	rx := zone.Rx * arcSecond
+	ry := zone.Ry * arcSecond
+	rz := zone.Rz * arcSecond
+
+	// Coordinate frame rotations have opposite sign
+	if zone.Convention == CoordinateFrame {
+		rx, ry, rz = -rx, -ry, -rz
+	}
+
+	// Build position vector matrix
+	return [3][3]float64{
+		{m, -m * rz, m * ry},
+		{m * rz, m, -m * rx},
+		{-m * ry, m * rx, m},
+	}
+}
+
+// Apply 7 parameter helmert transformation
+func applyHelmert(zone config.CSTransformation, x, y, z float64) (float64, float64, float64) {
+
+	// Get matrix
+	r := helmertMatrix(zone)
+
+	// Transform point
+	rx := zone.Tx + r[0][0]*x + r[0][1]*y + r[0][2]*z
+	ry := zone.Ty + r[1][0]*x + r[1][1]*y + r[1][2]*z
+	rz := zone.Tz + r[2][0]*x + r[2][1]*y + r[2][2]*z
+
+	return rx, ry, rz
+}
+
+// Apply inverse of 7 parameter helmert transformation, by solving the linear system
+func invertHelmert(zone config.CSTransformation, x, y, z float64) (float64, float64, float64, float64, error) {
+
+	// Get matrix
+	r := helmertMatrix(zone)
+
+	// Remove translation
+	v := [3]float64{x - zone.Tx, y - zone.Ty, z - zone.Tz}
+
+	// Get determinant
+	det := r[0][0]*(r[1][1]*r[2][2]-r[1][2]*r[2][1]) -
+		r[0][1]*(r[1][0]*r[2][2]-r[1][2]*r[2][0]) +
+		r[0][2]*(r[1][0]*r[2][1]-r[1][1]*r[2][0])
+	if det == 0 {
+		return x, y, z, math.Inf(1), errNotConverged
+	}
+
+	// Solve with Cramer's rule
+	var res [3]float64
+	for i := range res {
+
+		// Replace column
+		m := r
+		for j := range m {
+			m[j][i] = v[j]
+		}
+
+		// Get determinant
+		d := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+		res[i] = d / det
+	}
+
+	// Get residual
+	fx, fy, fz := applyHelmert(zone, res[0], res[1], res[2])
+	residual := math.Sqrt((fx-x)*(fx-x) + (fy-y)*(fy-y) + (fz-z)*(fz-z))
+
+	return res[0], res[1], res[2], residual, nil
+}
+
+// Get shift grid of ntv2 zone
+func ntv2Grid(zone config.CSTransformation) (*grids.NTv2, error) {
+	g, ok := Repo.ShiftGrids[zone.Grid]
+	if !ok {
+		return nil, fmt.Errorf("unknown shift grid %s", zone.Grid)
+	}
+	return g, nil
+}
+
+// Apply NTv2 shift to latitude and longitude in degrees
+func applyNTv2(zone config.CSTransformation, lat, lon float64) (float64, float64, error) {
+
+	// Get grid
+	g, err := ntv2Grid(zone)
+	if err != nil {
+		return lat, lon, err
+	}
+
+	// Get shift
+	dLat, dLon, ok := g.Shift(lat, lon)
+	if !ok {
+		return lat, lon, grids.ErrOutOfBounds
+	}
+
+	return lat + dLat, lon + dLon, nil
+}
+
+// Approximate length of a degree of latitude in meters, used to compare residuals with the tolerance
+const degreeLength = 111320
+
+// Apply inverse NTv2 shift, by iterating the shift at the estimated source point
+// Returns the residual in degrees
+func invertNTv2(zone config.CSTransformation, lat, lon float64) (float64, float64, float64, error) {
+
+	// Get grid
+	g, err := ntv2Grid(zone)
+	if err != nil {
+		return lat, lon, 0, err
+	}
+
+	// Get iteration settings
+	tolerance, maxIterations := inverseSettings()
+
+	// Start from target point
+	x, y := lat, lon
+	residual := math.Inf(1)
+	for i := 0; i < maxIterations; i++ {
+
+		// Get shift at estimate
+		dLat, dLon, ok := g.Shift(x, y)
+		if !ok {
+			return lat, lon, residual, grids.ErrOutOfBounds
+		}
+
+		// Get residual in degrees
+		rx, ry := x+dLat-lat, y+dLon-lon
+		residual = math.Hypot(rx, ry)
+		if residual*degreeLength < tolerance {
+			return x, y, residual, nil
+		}
+
+		// Update estimate
+		x -= rx
+		y -= ry
+	}
+
+	return x, y, residual, errNotConverged
+}
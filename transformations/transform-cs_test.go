@@ -0,0 +1,125 @@
+package transformations
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Test helmert transformation against the EPSG guidance note example
+func TestHelmert(t *testing.T) {
+
+	// WGS 72 to WGS 84, position vector convention
+	zone := config.CSTransformation{
+		Type: CSTypeHelmert,
+		Tz:   4.5,
+		Rz:   0.554,
+		Ds:   0.219,
+	}
+
+	// Transform point
+	x, y, z, err := forwardZone(zone, 3657660.66, 255768.55, 5201382.11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check result
+	if math.Abs(x-3657660.78) > 0.01 || math.Abs(y-255778.43) > 0.01 || math.Abs(z-5201387.75) > 0.01 {
+		t.Errorf("expected 3657660.78, 255778.43, 5201387.75; received %.3f, %.3f, %.3f", x, y, z)
+	}
+
+	// Coordinate frame convention should rotate the other way
+	zone.Convention = CoordinateFrame
+	_, y, _, _ = forwardZone(zone, 3657660.66, 255768.55, 5201382.11)
+	if math.Abs(y-255758.78) > 0.01 {
+		t.Errorf("expected coordinate frame Y 255758.78; received %.3f", y)
+	}
+
+	// Check inverse
+	zone.Convention = PositionVector
+	x, y, z, _, err = inverseZone(zone, 3657660.78, 255778.43, 5201387.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x-3657660.66) > 0.01 || math.Abs(y-255768.55) > 0.01 || math.Abs(z-5201382.11) > 0.01 {
+		t.Errorf("expected 3657660.66, 255768.55, 5201382.11; received %.3f, %.3f, %.3f", x, y, z)
+	}
+}
+
+// Test 2D conformal and affine transformations
+func TestPlanarMethods(t *testing.T) {
+
+	// Setup default solution parameters
+	Setup(&config.App{})
+
+	// Rotate by 90 degrees and double the scale
+	conformal := config.CSTransformation{
+		Type: CSTypeConformal,
+		Tx:   100,
+		Ty:   200,
+		Rz:   90 * 3600,
+		Ds:   1e6,
+	}
+	x, y, _, _ := forwardZone(conformal, 1, 2, 0)
+	if math.Abs(x-96) > 1e-9 || math.Abs(y-202) > 1e-9 {
+		t.Errorf("expected conformal 96, 202; received %f, %f", x, y)
+	}
+
+	// Affine transformation should ignore higher order terms
+	affine := config.CSTransformation{
+		Type: CSTypeAffine,
+		A00:  10,
+		A10:  2,
+		A20:  100,
+		B00:  20,
+		B01:  3,
+	}
+	x, y, _, _ = forwardZone(affine, 1, 2, 0)
+	if x != 12 || y != 26 {
+		t.Errorf("expected affine 12, 26; received %f, %f", x, y)
+	}
+
+	// Check affine inverse
+	x, y, _, _, err := inverseZone(affine, 12, 26, 0)
+	if err != nil || math.Abs(x-1) > 1e-6 || math.Abs(y-2) > 1e-6 {
+		t.Errorf("expected affine inverse 1, 2; received %f, %f", x, y)
+	}
+}
+
+// Test NTv2 shift and its inverse
+func TestNTv2Zone(t *testing.T) {
+
+	// Setup shift grid with a latitude shift growing to the north
+	Setup(&config.App{})
+	lat := grids.New(42, 22, 1, 1, 0, 0, 2, 2)
+	lon := grids.New(42, 22, 1, 1, 0, 0, 2, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			lat.Set(i, j, float64(i+1))
+			lon.Set(i, j, 2)
+		}
+	}
+	Repo.ShiftGrids = map[string]*grids.NTv2{
+		"bg": {Subgrids: []*grids.NTv2Subgrid{{LatShift: lat, LonShift: lon}}},
+	}
+	zone := config.CSTransformation{Type: CSTypeNTv2, Grid: "bg"}
+
+	// Check forward shift
+	x, y, _, err := forwardZone(zone, 42.5, 22.5, 0)
+	if err != nil || math.Abs(x-(42.5+1.5/3600)) > 1e-12 || math.Abs(y-(22.5+2.0/3600)) > 1e-12 {
+		t.Errorf("unexpected shift %f, %f %v", x, y, err)
+	}
+
+	// Check inverse
+	rx, ry, _, _, err := inverseZone(zone, x, y, 0)
+	if err != nil || math.Abs(rx-42.5) > 1e-9 || math.Abs(ry-22.5) > 1e-9 {
+		t.Errorf("expected inverse 42.5, 22.5; received %f, %f %v", rx, ry, err)
+	}
+
+	// Check point outside of grid
+	if _, _, _, _, ok, _ := applyZone("a", "b", zone, 41, 22.5, 0); ok {
+		t.Error("expected point outside of shift grid to be out of zone")
+	}
+}
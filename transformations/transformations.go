@@ -2,8 +2,19 @@ package transformations
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
 
 	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Grid file formats
+const (
+	GridFormatSQLite = "sqlite"
+	GridFormatBinary = "binary"
+	GridFormatGTX    = "gtx"
+	GridFormatISG    = "isg"
 )
 
 // Type repository
@@ -13,23 +24,54 @@ type Repository struct {
 	ValidHSs map[string]bool
 	CSGraph  CSTransformationGraph
 	HSGraph  HSTransformationGraph
+
+	Ellipsoids map[string]config.Ellipsoid
+	Grids      map[string]*grids.Grid
+
+	// Standard deviation grids of grid transformations
+	AccuracyGrids map[string]*grids.Grid
+
+	ShiftGrids map[string]*grids.NTv2
 }
 
 // Define repo
 var Repo Repository
 
+// Default grid models directory
+const defaultGridPath = "/grid-models"
+
+// Default grid coordinate system
+const defaultGridCS = "bgs-cad"
+
+// Get coordinate system, a grid is defined in
+func gridSystem(params config.HGridTransformation) string {
+	if params.CS == "" {
+		return defaultGridCS
+	}
+	return params.CS
+}
+
 // Setup repo
-func Setup(a *config.App) {
+func Setup(a *config.App) error {
 
 	// Add app to repo
 	Repo = Repository{
 		App:      a,
 		ValidCSs: map[string]bool{},
 		ValidHSs: map[string]bool{},
-		CSGraph:  CSTransformationGraph{data: a.CsGraph},
+		CSGraph:  CSTransformationGraph{data: deriveInverses(a.CsGraph)},
 		HSGraph:  HSTransformationGraph{data: a.HsGraph, methods: a.HTransformations},
 	}
 
+	// Add predefined and configured ellipsoids
+	Repo.Ellipsoids = map[string]config.Ellipsoid{}
+	for name, e := range defaultEllipsoids {
+		Repo.Ellipsoids[name] = e
+	}
+	for name, e := range a.Ellipsoids {
+		Repo.Ellipsoids[name] = e
+	}
+
 	// Covert valid CSs to Repo
 	for _, cs := range a.ValidCSs {
 		Repo.ValidCSs[cs] = true
@@ -39,6 +81,179 @@ func Setup(a *config.App) {
 	for _, hs := range a.ValidHSs {
 		Repo.ValidHSs[hs] = true
 	}
+
+	// Load grids
+	var err error
+	Repo.Grids, Repo.AccuracyGrids, err = loadGrids(a)
+	if err != nil {
+		return err
+	}
+
+	// Load shift grids
+	Repo.ShiftGrids, err = loadShiftGrids(a)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get grid models directory
+func gridPath(a *config.App) string {
+	if a.GridPath == "" {
+		return defaultGridPath
+	}
+	return a.GridPath
+}
+
+// Load all grid transformations and their accuracy grids in memory
+func loadGrids(a *config.App) (map[string]*grids.Grid, map[string]*grids.Grid, error) {
+
+	// Store result
+	res := make(map[string]*grids.Grid, len(a.HTransformations.Grid))
+	accuracy := map[string]*grids.Grid{}
+
+	// Iterate over grids
+	for name, params := range a.HTransformations.Grid {
+
+		// Load grid and check interpolation method
+		g, err := loadGridFile(a, params, params.DB)
+		if err == nil {
+			err = grids.ValidInterpolation(gridMethod(params))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't load grid %s: %w", name, err)
+		}
+
+		// Add grid
+		res[name] = g
+
+		// Skip grids without accuracy
+		if params.AccuracyDB == "" {
+			continue
+		}
+
+		// Load accuracy grid
+		acc, err := loadGridFile(a, params, params.AccuracyDB)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't load accuracy grid %s: %w", name, err)
+		}
+		accuracy[name] = acc
+	}
+
+	return res, accuracy, nil
+}
+
+// Load grid file and check it against configuration
+func loadGridFile(a *config.App, params config.HGridTransformation, file string) (*grids.Grid, error) {
+
+	// Get file path
+	path := filepath.Join(gridPath(a), file)
+
+	// Track file units
+	units := ""
+
+	// Load grid
+	var g *grids.Grid
+	var err error
+	switch params.Format {
+	case GridFormatSQLite, "":
+		g, err = grids.LoadSQLite(path, params.X0, params.Y0, params.GridSize)
+	case GridFormatBinary:
+		g, err = grids.LoadBinaryFile(path)
+	case GridFormatGTX:
+		g, err = grids.LoadGTXFile(path)
+	case GridFormatISG:
+		var h grids.ISGHeader
+		g, h, err = grids.LoadISGFile(path)
+		units = h.Units
+	default:
+		err = fmt.Errorf("unsupported format %s", params.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check header
+	if err := checkGridHeader(g, params, units); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Check grid file metadata against configuration
+func checkGridHeader(g *grids.Grid, params config.HGridTransformation, units string) error {
+
+	// Tolerance for comparing header values
+	const eps = 1e-9
+
+	// Get expected spacing
+	dy := params.GridSizeY
+	if dy == 0 {
+		dy = params.GridSize
+	}
+
+	// Check spacing
+	if math.Abs(g.DX-params.GridSize) > eps || math.Abs(g.DY-dy) > eps {
+		return fmt.Errorf("grid spacing %g/%g does not match configured %g/%g", g.DX, g.DY, params.GridSize, dy)
+	}
+
+	// Check that grid nodes are aligned with configured origin
+	for _, steps := range []float64{(g.X0 - params.X0) / g.DX, (g.Y0 - params.Y0) / g.DY} {
+		if math.Abs(steps-math.Round(steps)) > 1e-6 {
+			return fmt.Errorf("grid origin %g/%g is not aligned with configured %g/%g", g.X0, g.Y0, params.X0, params.Y0)
+		}
+	}
+
+	// Check units
+	expected := params.Units
+	if expected == "" {
+		expected = "meters"
+	}
+	if units != "" && units != expected {
+		return fmt.Errorf("grid units %s do not match configured %s", units, expected)
+	}
+
+	// Check if grid has data
+	if g.Rows == 0 || g.Cols == 0 {
+		return fmt.Errorf("%s", "grid is empty")
+	}
+
+	return nil
+}
+
+// Load all shift grids in memory
+func loadShiftGrids(a *config.App) (map[string]*grids.NTv2, error) {
+
+	// Store result
+	res := make(map[string]*grids.NTv2, len(a.ShiftGrids))
+
+	// Iterate over grids
+	for name, params := range a.ShiftGrids {
+
+		// Load grid
+		g, err := grids.LoadNTv2File(filepath.Join(gridPath(a), params.File))
+		if err != nil {
+			return nil, fmt.Errorf("can't load shift grid %s: %w", name, err)
+		}
+
+		// Check header
+		if params.SystemFrom != "" && params.SystemFrom != g.SystemFrom {
+			return nil, fmt.Errorf("shift grid %s: source system %s does not match configured %s", name, g.SystemFrom, params.SystemFrom)
+		}
+		if params.SystemTo != "" && params.SystemTo != g.SystemTo {
+			return nil, fmt.Errorf("shift grid %s: target system %s does not match configured %s", name, g.SystemTo, params.SystemTo)
+		}
+		if len(g.Subgrids) == 0 {
+			return nil, fmt.Errorf("shift grid %s: no sub grids", name)
+		}
+
+		// Add grid
+		res[name] = g
+	}
+
+	return res, nil
 }
 
 // Get transformer
@@ -69,64 +284,47 @@ func GetTransformer(ics, ocs, ihs, ohs string) (Transformer, error) {
 	}
 
 	// Check if a grid transformation is in the path
-	storeBgs := false
-
-	// Get first system
-	from := ihs
+	// Grid coordinates are needed in the system, the first grid is defined in
+	gridCS := ""
 
 	// Traverse graph
-	for {
-
-		// Get node
-		to, ok := hsPath[from]
-
-		// Exit if not found
-		if !ok {
-			break
-		}
+	for _, hop := range pathHops(hsPath, ihs) {
 
 		// Get params
-		params, _ := Repo.HSGraph.Get(from, to[0])
-
-		// If params are not grid base
-		if params.Name != "grid" {
+		params, _ := Repo.HSGraph.Get(hop[0], hop[1])
 
-			// Update from
-			from = to[0]
-
-			// Continue
+		// Skip if params are not grid base
+		if params.Type != HSTypeGrid {
 			continue
 		}
 
-		// Will need bgs coordiantes
-		storeBgs = true
+		// Will need grid coordiantes
+		gridCS = gridSystem(Repo.App.HTransformations.Grid[params.Name])
 
 		// Exit
 		break
 	}
 
-	// Set cs transformation targets
-	csTargets := [2]string{ocs}
-
-	// Add bgs if needed
-	if storeBgs {
-		csTargets[1] = "bgs-cad"
+	// Set cs transformation targets, adding the grid system if needed
+	csTargets := [2]string{ocs, gridCS}
+	if gridCS == ocs {
+		csTargets[1] = ""
 	}
 
-	// Find path from input CS to output CS, going trough BGS if needed
+	// Find path from input CS to output CS, going trough grid system if needed
 	csPath, found := findPathGraph(Repo.CSGraph.data, ics, csTargets)
 	if !found {
 		return nil, fmt.Errorf("can't convert from %s to %s", ics, ocs)
 	}
 
 	return &TransformerOutput{
-		csPath:       csPath,
-		hsPath:       hsPath,
-		includesGrid: storeBgs,
-		ics:          ics,
-		ihs:          ihs,
-		ocs:          ocs,
-		ohs:          ohs,
-		points:       make(map[int]*PointResult),
+		csPath: csPath,
+		hsPath: hsPath,
+		gridCS: gridCS,
+		ics:    ics,
+		ihs:    ihs,
+		ocs:    ocs,
+		ohs:    ohs,
+		points: make(map[int]*PointResult),
 	}, nil
 }
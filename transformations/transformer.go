@@ -2,47 +2,178 @@ package transformations
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
-	"strings"
-	"time"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
 )
 
 // Transformer interface
 type Transformer interface {
+	SetOptions(o Options)
 	Add(id int, pt *PointResult)
+	Reset()
 	TransformBatch() (map[int]*PointResult, error)
+	TransformBatchContext(ctx context.Context) (map[int]*PointResult, error)
+}
+
+// Transformation options
+type Options struct {
+	// Record the transformation steps for every point
+	Explain bool
+
+	// Number of concurrent workers, defaults to the configured number
+	Workers int
+
+	// Blend zones for points near zone borders
+	Blend bool
 }
 
+// Point error codes
+const (
+	ErrCodeParseX       = "parse_x"
+	ErrCodeParseY       = "parse_y"
+	ErrCodeParseH       = "parse_h"
+	ErrCodeParseZ       = "parse_z"
+	ErrCodeOutOfBounds  = "out_of_bounds"
+	ErrCodeNotConverged = "not_converged"
+	ErrCodeUnsupported  = "unsupported"
+)
+
 // Store transformation intermediate steps
 type PointResult struct {
 	Name string
+	Code string
+
+	X         float64
+	Y         float64
+	XYErr     string
+	XYErrCode string
 
-	X     float64
-	Y     float64
-	XYErr string
+	H        float64
+	HasH     bool
+	HErr     string
+	HErrCode string
 
-	H    float64
-	HasH bool
-	HErr string
+	// Third coordinate for 3D systems, like ellipsoidal height or geocentric Z
+	// Without an input Z, the input height H is used, so geographic heights are treated as ellipsoidal
+	Z    float64
+	HasZ bool
 
+	// Coordinates in the grid system
 	Xbgs float64
 	Ybgs float64
 
+	// Estimated standard deviations of the transformation in meters
+	// Unknown if a hop on the path has no stated accuracy
+	SigmaXY    float64
+	HasSigmaXY bool
+	SigmaH     float64
+	HasSigmaH  bool
+
+	// Largest distance between results of blended zones
+	Discrepancy float64
+
 	Var []string
+
+	Zones []ZoneHit
+	Trace *PointTrace
+}
+
+// CS transformation zone, used for a point
+type ZoneHit struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Zone int    `json:"zone"`
+	Name string `json:"name,omitempty"`
+
+	// Weight of blended zones
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// Point transformation steps
+type PointTrace struct {
+	CSPath map[string][]string `json:"csPath"`
+	HSPath []string            `json:"hsPath"`
+	CSHops []CSHopTrace        `json:"csHops"`
+	BGS    *[2]float64         `json:"bgs,omitempty"`
+	HSHops []HSHopTrace        `json:"hsHops"`
+}
+
+// CS hop step
+type CSHopTrace struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Zone     int     `json:"zone"`
+	ZoneName string  `json:"zoneName,omitempty"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Z        float64 `json:"z"`
+
+	// Convergence residual for derived inverse hops
+	Residual float64 `json:"residual,omitempty"`
+
+	// Input height H was used as ellipsoidal height, because the point has no Z
+	HeightAsEllipsoidal bool `json:"heightAsEllipsoidal,omitempty"`
+
+	// Standard deviation of the zone
+	Sigma *float64 `json:"sigma,omitempty"`
+
+	// Zone results of blended hops and the largest distance between them
+	Blend       []BlendTrace `json:"blend,omitempty"`
+	Discrepancy float64      `json:"discrepancy,omitempty"`
+}
+
+// Zone result of a blended CS hop
+type BlendTrace struct {
+	Zone     int     `json:"zone"`
+	ZoneName string  `json:"zoneName,omitempty"`
+	Weight   float64 `json:"weight"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Z        float64 `json:"z"`
+	Residual float64 `json:"residual,omitempty"`
+}
+
+// HS hop step
+type HSHopTrace struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Correction float64 `json:"correction"`
+	H          float64 `json:"h"`
+
+	// Grid interpolation method
+	Interpolation string `json:"interpolation,omitempty"`
+
+	// Bilinear interpolation was used, because the nodes of the configured method were incomplete
+	Fallback bool `json:"interpolationFallback,omitempty"`
+
+	// Standard deviation of the hop
+	Sigma *float64 `json:"sigma,omitempty"`
 }
 
 // Transform output type
 type TransformerOutput struct {
-	csPath       map[string][]string
-	hsPath       map[string][]string
-	includesGrid bool
-	ics          string
-	ihs          string
-	ocs          string
-	ohs          string
-	points       map[int]*PointResult
+	csPath  map[string][]string
+	hsPath  map[string][]string
+	gridCS  string
+	ics     string
+	ihs     string
+	ocs     string
+	ohs     string
+	options Options
+	points  map[int]*PointResult
+}
+
+// Set transformation options
+func (t *TransformerOutput) SetOptions(o Options) {
+	t.options = o
 }
 
 // Add points to transformation batch
@@ -52,311 +183,485 @@ func (t *TransformerOutput) Add(id int, pt *PointResult) {
 	t.points[id] = pt
 }
 
+// Remove all points from batch, keeping paths and options
+func (t *TransformerOutput) Reset() {
+	t.points = make(map[int]*PointResult)
+}
+
 // Trasnform batch
 func (t *TransformerOutput) TransformBatch() (map[int]*PointResult, error) {
+	return t.TransformBatchContext(context.Background())
+}
+
+// Transform batch with a worker pool, stopping when the context is cancelled
+func (t *TransformerOutput) TransformBatchContext(ctx context.Context) (map[int]*PointResult, error) {
 
-	// Get base cs
-	from := t.ics
+	// Get ordered hs path
+	hsPath := walkPath(t.hsPath, t.ihs)
 
-	// Iterate points
-	for key, pt := range t.points {
+	// Resolve HS transformation parameters
+	hops, err := resolveHSHops(hsPath)
+	if err != nil {
+		return nil, err
+	}
 
-		// Store intermediate results
-		type IntRes struct {
-			CS string
-			X  float64
-			Y  float64
+	// Get point ids in order
+	keys := make([]int, 0, len(t.points))
+	for key := range t.points {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	// Get number of workers
+	workers := t.options.Workers
+	if workers <= 0 {
+		workers = Repo.App.Workers
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(keys))
+
+	// Transform serially
+	if workers <= 1 {
+		for _, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := t.transformPoint(t.points[key], hsPath, hops); err != nil {
+				return nil, err
+			}
 		}
+		return t.points, nil
+	}
 
-		// Track walk nodes
-		fromNodes := []IntRes{
-			{
-				CS: t.ics,
-				X:  pt.X,
-				Y:  pt.Y,
-			},
+	// Create worker context, cancelled on first error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Track first error
+	var once sync.Once
+	var firstErr error
+
+	// Start workers
+	// Every point is updated by a single worker, so results don't depend on scheduling
+	jobs := make(chan []int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				for _, key := range chunk {
+					if err := t.transformPoint(t.points[key], hsPath, hops); err != nil {
+						once.Do(func() {
+							firstErr = err
+							cancel()
+						})
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	// Send points in chunks
+send:
+	for start := 0; start < len(keys); start += workerChunkSize {
+		select {
+		case jobs <- keys[start:min(start+workerChunkSize, len(keys))]:
+		case <-ctx.Done():
+			break send
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		// Store results
-		res := map[string][2]float64{}
+	// Return error
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		// Walk the graph
-	graphLoop:
-		for len(fromNodes) > 0 {
+	return t.points, nil
+}
 
-			nextNodes := []IntRes{}
+// Number of points, sent to a worker at once
+const workerChunkSize = 256
 
-			// Iterate nodes
-			for _, node := range fromNodes {
+// HS hop with resolved parameters
+type hsHop struct {
+	from   string
+	to     string
+	params config.HSTransformation
 
-				// If current node is of interest, store values
-				if node.CS == t.ocs {
-					res[t.ocs] = [2]float64{node.X, node.Y}
-				}
-				if t.includesGrid && node.CS == "bgs-cad" {
-					res["bgs-cad"] = [2]float64{node.X, node.Y}
-				}
+	// Grid, accuracy grid and interpolation method of grid hops
+	grid     *grids.Grid
+	accuracy *grids.Grid
+	method   string
 
-				// Find connections
-				connections, found := t.csPath[node.CS]
+	// Parameters of plane hops
+	plane config.HPlaneTransformation
+}
 
-				// If no connections, continue
-				if !found {
-					continue
-				}
+// Resolve parameters of HS path hops
+func resolveHSHops(hsPath []string) ([]hsHop, error) {
 
-				// Iterate connections
-				for _, to := range connections {
+	// Store result
+	res := make([]hsHop, 0, len(hsPath))
 
-					// Create next node
-					nextNode := IntRes{
-						CS: to,
-						X:  node.X,
-						Y:  node.Y,
-					}
+	// Iterate over HS transformation path
+	for i := 1; i < len(hsPath); i++ {
 
-					// Get CS trasnformation parameters
-					zones, ok := Repo.CSGraph.Get(from, to)
-					if !ok {
-						return nil, errors.ErrUnsupported
-					}
+		// Get hop
+		hop := hsHop{from: hsPath[i-1], to: hsPath[i]}
 
-					// Track if point is transformed
-					transformed := false
-
-					// Iterate over zones
-					for _, zone := range zones {
-
-						// Check if point is in zone
-						if !zone.InZone(nextNode.X, nextNode.Y) {
-							continue
-						}
-
-						// Helper values
-						dx := nextNode.X - zone.X0
-						dy := nextNode.Y - zone.Y0
-
-						// Transform point
-						nextNode.X = zone.A00 +
-							zone.A10*dx +
-							zone.A01*dy +
-							zone.A20*dx*dx +
-							zone.A11*dx*dy +
-							zone.A02*dy*dy +
-							zone.A30*dx*dx*dx +
-							zone.A21*dx*dx*dy +
-							zone.A12*dx*dy*dy +
-							zone.A03*dy*dy*dy
-						nextNode.Y = zone.B00 +
-							zone.B10*dx +
-							zone.B01*dy +
-							zone.B20*dx*dx +
-							zone.B11*dx*dy +
-							zone.B02*dy*dy +
-							zone.B30*dx*dx*dx +
-							zone.B21*dx*dx*dy +
-							zone.B12*dx*dy*dy +
-							zone.B03*dy*dy*dy
+		// Get HS trasnformation parameters
+		params, ok := Repo.HSGraph.Get(hop.from, hop.to)
+		if !ok {
+			return nil, errors.ErrUnsupported
+		}
+		hop.params = params
+
+		// Get method parameters
+		switch params.Type {
+		case HSTypeGrid:
+			hop.grid, ok = Repo.Grids[params.Name]
+			hop.accuracy = Repo.AccuracyGrids[params.Name]
+			hop.method = gridMethod(Repo.HSGraph.methods.Grid[params.Name])
+		case HSTypePlane:
+			hop.plane, ok = Repo.HSGraph.methods.Plane[params.Name]
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, errors.ErrUnsupported
+		}
 
-						// Mark as tranformed
-						transformed = true
+		// Add hop
+		res = append(res, hop)
+	}
 
-						// Exit loop
-						break
-					}
+	return res, nil
+}
 
-					// Return error if not transformed
-					if !transformed {
-						pt.XYErr = "point out of transformation bounds"
-						break graphLoop
-					}
+// Transform a single point through the CS and HS paths
+func (t *TransformerOutput) transformPoint(pt *PointResult, hsPath []string, hops []hsHop) error {
 
-					// Add next node
-					nextNodes = append(nextNodes, nextNode)
-				}
-			}
+	// Clear zones
+	pt.Zones = nil
 
-			// Update from nodes
-			fromNodes = nextNodes
+	// Start trace
+	if t.options.Explain {
+		pt.Trace = &PointTrace{
+			CSPath: t.csPath,
+			HSPath: hsPath,
 		}
+	}
 
-		// If there is a transformation error
-		if len(pt.XYErr) > 0 {
-
-			// Update point
-			t.points[key] = pt
+	// Store intermediate results with the accumulated variance
+	// ZFromH marks a third coordinate taken from the input height
+	type IntRes struct {
+		CS     string
+		X      float64
+		Y      float64
+		Z      float64
+		ZFromH bool
+		Var    float64
+		Known  bool
+	}
 
-			// Continue to next point
-			continue
-		}
+	// Start from input Z, or from height if there is none
+	z := pt.H
+	if pt.HasZ {
+		z = pt.Z
+	}
 
-		// Update point coordinates
-		pt.X = res[t.ocs][0]
-		pt.Y = res[t.ocs][1]
-		if t.includesGrid {
-			pt.Xbgs = res["bgs-cad"][0]
-			pt.Ybgs = res["bgs-cad"][1]
-		}
-		t.points[key] = pt
+	// Track walk nodes
+	fromNodes := []IntRes{
+		{
+			CS:     t.ics,
+			X:      pt.X,
+			Y:      pt.Y,
+			Z:      z,
+			ZFromH: !pt.HasZ,
+			Known:  true,
+		},
 	}
 
-	// Get base hs
-	from = t.ihs
+	// Store results
+	res := map[string]IntRes{}
 
-	// Iterate over HS transformation path
-	for _, to := range t.hsPath {
+	// Walk the graph
+graphLoop:
+	for len(fromNodes) > 0 {
 
-		// Get CS trasnformation parameters
-		params, ok := Repo.HSGraph.Get(from, to[0])
-		if !ok {
-			return nil, errors.ErrUnsupported
-		}
+		nextNodes := []IntRes{}
 
-		// Update from
-		from = to[0]
+		// Iterate nodes
+		for _, node := range fromNodes {
 
-		// If grid type
-		if params.Type == "grid" {
+			// If current node is of interest, store values
+			if node.CS == t.ocs {
+				res[t.ocs] = node
+			}
+			if t.gridCS != "" && node.CS == t.gridCS {
+				res[t.gridCS] = node
+			}
+
+			// Find connections
+			connections, found := t.csPath[node.CS]
 
-			// Get grid params
-			gridParams, ok := Repo.HSGraph.methods.Grid[params.Name]
-			if !ok {
-				return nil, errors.ErrUnsupported
+			// If no connections, continue
+			if !found {
+				continue
 			}
 
-			// Store grid vertices
-			verticesList := []string{}
+			// Iterate connections
+			for _, to := range connections {
 
-			// Iterate over points
-			for _, pt := range t.points {
+				// Create next node
+				nextNode := node
+				nextNode.CS = to
 
-				// Skip if H is missing or if there is a XY err
-				if !(pt.HasH && len(pt.XYErr) == 0) {
-					continue
+				// Get CS trasnformation parameters
+				zones, ok := Repo.CSGraph.Get(node.CS, to)
+				if !ok {
+					return errors.ErrUnsupported
 				}
 
-				// Build vertex name
-				name := makeOndulationVertexNameFromXY(gridParams, pt.Xbgs, pt.Ybgs)
+				// Track if point is transformed
+				transformed := false
+				var solveErr error
 
-				// Add to points
-				verticesList = append(verticesList, name)
-			}
+				// Blend zones for points near borders
+				if t.options.Blend {
+					blended, err := blendZones(zones, nextNode.X, nextNode.Y, nextNode.Z, blendBuffer())
+					if err != nil {
+						solveErr = err
+					}
+					if len(blended) > 1 {
 
-			// Open DB
-			// TODO: Make path configurable
-			db, err := sql.Open("sqlite3", fmt.Sprintf("/grid-models/%s", gridParams.DB))
-			if err != nil {
-				return nil, err
-			}
+						// Add zone variance
+						sigma, known := blendSigma(zones, blended, nextNode.X, nextNode.Y)
+						nextNode.Var += sigma * sigma
+						nextNode.Known = nextNode.Known && known
 
-			// Close db on end
-			defer db.Close()
+						// Combine results
+						var discrepancy float64
+						nextNode.X, nextNode.Y, nextNode.Z, discrepancy = combineZones(blended)
+						pt.Discrepancy = math.Max(pt.Discrepancy, discrepancy)
 
-			// Ping DB
-			if err = db.Ping(); err != nil {
-				return nil, err
-			}
+						// Mark as tranformed
+						transformed = true
 
-			const maxOpenDbConn = 10
-			const maxIdleDbConn = 5
-			const maxDbLifetime = 5 * time.Minute
+						// Record zones and step
+						pt.traceBlend(node.CS, to, zones, blended, CSHopTrace{
+							From:        node.CS,
+							To:          to,
+							X:           nextNode.X,
+							Y:           nextNode.Y,
+							Z:           nextNode.Z,
+							Sigma:       sigmaPtr(sigma, known),
+							Discrepancy: discrepancy,
+						})
+					}
+				}
 
-			// Configure connection
-			db.SetMaxOpenConns(maxOpenDbConn)
-			db.SetMaxIdleConns(maxIdleDbConn)
-			db.SetConnMaxLifetime(maxDbLifetime)
+				// Iterate over zones, unless blended
+				for i := 0; i < len(zones) && !transformed; i++ {
 
-			// Define context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-			defer cancel()
+					// Get zone
+					zone := zones[i]
 
-			// Build query
-			query := fmt.Sprintf("SELECT * FROM undulation_points WHERE id IN (%s);", strings.Join(verticesList, ", "))
+					// Transform point
+					x, y, z, residual, ok, err := applyZone(node.CS, to, zone, nextNode.X, nextNode.Y, nextNode.Z)
+					if err != nil {
+						solveErr = err
+					}
+					if !ok {
+						continue
+					}
 
-			// Get rows
-			rows, err := db.QueryContext(ctx, query)
-			if err != nil {
-				return nil, err
-			}
-			defer rows.Close()
-
-			// Store cached data
-			vertices := make(map[string]float64)
-
-			// Scan rows
-			for rows.Next() {
-
-				// Define base models
-				c := struct {
-					ID string
-					H  float64
-				}{}
-
-				err = rows.Scan(
-					&c.ID,
-					&c.H,
-				)
-				if err != nil {
-					return nil, err
+					// Add zone variance
+					sigma, known := zoneSigma(zone, nextNode.X, nextNode.Y)
+					nextNode.Var += sigma * sigma
+					nextNode.Known = nextNode.Known && known
+
+					nextNode.X = x
+					nextNode.Y = y
+					nextNode.Z = z
+
+					// Mark as tranformed
+					transformed = true
+
+					// Record zone
+					pt.Zones = append(pt.Zones, ZoneHit{From: node.CS, To: to, Zone: i, Name: zone.Name})
+
+					// Record step
+					if pt.Trace != nil {
+						pt.Trace.CSHops = append(pt.Trace.CSHops, CSHopTrace{
+							From:     node.CS,
+							To:       to,
+							Zone:     i,
+							ZoneName: zone.Name,
+							X:        nextNode.X,
+							Y:        nextNode.Y,
+							Z:        nextNode.Z,
+							Residual: residual,
+							Sigma:    sigmaPtr(sigma, known),
+
+							HeightAsEllipsoidal: node.ZFromH && CSKind(node.CS) == KindGeographic && CSKind(to) == KindGeocentric,
+						})
+					}
+
+					// Exit loop
+					break
 				}
 
-				// Add to accounts
-				vertices[c.ID] = c.H
-			}
+				// Return error if not transformed
+				if !transformed && solveErr == errNotConverged {
+					pt.XYErr = solveErr.Error()
+					pt.XYErrCode = ErrCodeNotConverged
+					break graphLoop
+				}
+				if !transformed && solveErr != nil {
+					pt.XYErr = solveErr.Error()
+					pt.XYErrCode = ErrCodeUnsupported
+					break graphLoop
+				}
+				if !transformed {
+					pt.XYErr = "point out of transformation bounds"
+					pt.XYErrCode = ErrCodeOutOfBounds
+					break graphLoop
+				}
 
-			// Check for errors
-			err = rows.Err()
-			if err != nil {
-				return nil, err
+				// Add next node
+				nextNodes = append(nextNodes, nextNode)
 			}
+		}
 
-			// Iterate over points
-			for key, pt := range t.points {
-
-				// Get height
-				hr, err := gridInterpolation(gridParams, pt.Xbgs, pt.Ybgs, pt.H, params.Direction, vertices)
-				if err != nil {
-					return nil, err
-				}
+		// Update from nodes
+		fromNodes = nextNodes
+	}
 
-				// Update H
-				pt.H = hr
-				t.points[key] = pt
-			}
+	// If there is a transformation error, skip height transformation
+	if len(pt.XYErr) > 0 {
+		return nil
+	}
 
-			// Continue
-			continue
+	// Update point coordinates
+	out := res[t.ocs]
+	pt.X = out.X
+	pt.Y = out.Y
+	pt.Z = out.Z
+	pt.SigmaXY = math.Sqrt(out.Var)
+	pt.HasSigmaXY = out.Known
+	if t.gridCS != "" {
+		pt.Xbgs = res[t.gridCS].X
+		pt.Ybgs = res[t.gridCS].Y
+
+		// Record bgs coordinates
+		if pt.Trace != nil {
+			pt.Trace.BGS = &[2]float64{pt.Xbgs, pt.Ybgs}
+		}
+	}
 
-			// If Plane
-		} else if params.Type == "plane" {
+	// Track height variance
+	variance := 0.0
+	known := true
 
-			// Get grid params
-			planeParams, ok := Repo.HSGraph.methods.Plane[params.Name]
-			if !ok {
-				return nil, errors.ErrUnsupported
-			}
+	// Iterate over HS hops
+	for _, hop := range hops {
 
-			// Iterate over points
-			for key, pt := range t.points {
+		// Skip points without a valid height
+		if !pt.hasValidH() {
+			return nil
+		}
 
-				// Get height
-				hr, err := planeInterpolation(planeParams, pt.X, pt.Y, pt.H, params.Direction)
-				if err != nil {
-					return nil, err
-				}
+		// Get height
+		var hr float64
+		var err error
+		method := hop.method
+		if hop.params.Type == HSTypeGrid {
+			hr, method, err = gridInterpolation(hop.grid, hop.method, pt.Xbgs, pt.Ybgs, pt.H, hop.params.Direction)
+		} else {
+			hr, err = planeInterpolation(hop.plane, pt.X, pt.Y, pt.H, hop.params.Direction)
+		}
 
-				// Update H
-				pt.H = hr
-				t.points[key] = pt
-			}
+		// Record error
+		if err == grids.ErrOutOfBounds {
+			pt.HErr = "point out of grid bounds"
+			pt.HErrCode = ErrCodeOutOfBounds
+			return nil
+		}
+		if err != nil {
+			pt.HErr = err.Error()
+			pt.HErrCode = ErrCodeUnsupported
+			return nil
+		}
 
-			// Continue
-			continue
+		// Add hop variance
+		var sigma float64
+		var ok bool
+		if hop.params.Type == HSTypeGrid {
+			sigma, ok = hopSigma(hop, pt.Xbgs, pt.Ybgs)
+		} else {
+			sigma, ok = hopSigma(hop, pt.X, pt.Y)
 		}
+		variance += sigma * sigma
+		known = known && ok
+
+		// Record step
+		pt.traceHS(hop.from, hop.to, hop.params, hop.method, method, hr, sigmaPtr(sigma, ok))
 
-		// Unsuported method
-		return nil, errors.ErrUnsupported
+		// Update H
+		pt.H = hr
 	}
 
-	return t.points, nil
+	// Update height uncertainty
+	if pt.hasValidH() {
+		pt.SigmaH = math.Sqrt(variance)
+		pt.HasSigmaH = known
+	}
+
+	return nil
+}
+
+// Get standard deviation for traces, nil if unknown
+func sigmaPtr(sigma float64, known bool) *float64 {
+	if !known {
+		return nil
+	}
+	return &sigma
+}
+
+// Check if point has a height, that can be transformed
+func (pt *PointResult) hasValidH() bool {
+	return pt.HasH && len(pt.XYErr) == 0 && len(pt.HErr) == 0
+}
+
+// Record HS hop step
+// The used method differs from the configured one when interpolation fell back to bilinear
+func (pt *PointResult) traceHS(from, to string, params config.HSTransformation, method, used string, hr float64, sigma *float64) {
+
+	// Skip if not explaining
+	if pt.Trace == nil {
+		return
+	}
+
+	// Add step
+	pt.Trace.HSHops = append(pt.Trace.HSHops, HSHopTrace{
+		From:          from,
+		To:            to,
+		Type:          params.Type,
+		Name:          params.Name,
+		Correction:    hr - pt.H,
+		H:             hr,
+		Interpolation: used,
+		Fallback:      used != method,
+		Sigma:         sigma,
+	})
 }
@@ -0,0 +1,349 @@
+package transformations
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Test that grid errors are reported per point
+func TestGridErrorsPerPoint(t *testing.T) {
+
+	// Setup app state with an identity CS hop and a grid HS hop
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs2"},
+		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {{Type: CSTypeAffine, A10: 1, B01: 1}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypeGrid, Name: "geoid", Direction: -1}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs2", GridSize: 100}}
+	g := grids.New(0, 0, 100, 100, 0, 0, 2, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			g.Set(i, j, 40)
+		}
+	}
+	Repo.Grids = map[string]*grids.Grid{"geoid": g}
+
+	// Get transformer
+	tr, err := GetTransformer("cs1", "cs2", "hs1", "hs2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add points inside and outside of the grid, and a point without height
+	tr.Add(0, &PointResult{X: 50, Y: 50, H: 100, HasH: true})
+	tr.Add(1, &PointResult{X: 500, Y: 50, H: 100, HasH: true})
+	tr.Add(2, &PointResult{X: 50, Y: 50})
+
+	// Transform
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatalf("expected partial results, received %v", err)
+	}
+
+	// Check results
+	if res[0].H != 60 || len(res[0].HErr) > 0 {
+		t.Errorf("expected height 60, received %f %s", res[0].H, res[0].HErr)
+	}
+	if res[1].HErrCode != ErrCodeOutOfBounds {
+		t.Errorf("expected %s error, received '%s'", ErrCodeOutOfBounds, res[1].HErrCode)
+	}
+	if res[2].H != 0 || len(res[2].HErr) > 0 {
+		t.Errorf("expected point without height to be skipped, received %f %s", res[2].H, res[2].HErr)
+	}
+}
+
+// Setup a route with a derived inverse hop and a plane HS hop
+func setupBatchRoute(tb testing.TB) {
+
+	// Setup app state
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs2"},
+		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {{Type: CSTypeAffine, A00: 100, A10: 1.0001, A01: 0.0002, B00: 200, B10: -0.0002, B01: 0.9999, Invertible: true}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypePlane, Name: "ptr", Direction: 1}},
+		},
+		HTransformations: config.TransformationMethods{
+			Plane: map[string]config.HPlaneTransformation{"ptr": {A: 0.2, B: 1e-6, C: -1e-6}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// Create transformer with n points
+func batchTransformer(tb testing.TB, n int, o Options) Transformer {
+
+	// Get transformer through the derived inverse
+	tr, err := GetTransformer("cs2", "cs1", "hs1", "hs2")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tr.SetOptions(o)
+
+	// Add points
+	for i := 0; i < n; i++ {
+		tr.Add(i, &PointResult{Name: fmt.Sprint(i), X: 4700000 + float64(i), Y: 400000 - float64(i), H: 500, HasH: true})
+	}
+
+	return tr
+}
+
+// Test that parallel transformation matches serial transformation
+func TestParallelBatch(t *testing.T) {
+
+	// Setup route
+	setupBatchRoute(t)
+
+	// Transform serially and in parallel
+	serial, err := batchTransformer(t, 1000, Options{Workers: 1}).TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := batchTransformer(t, 1000, Options{Workers: 8}).TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare results
+	for i, pt := range serial {
+		if len(pt.XYErr) > 0 {
+			t.Fatalf("point %d: %s", i, pt.XYErr)
+		}
+		if p := parallel[i]; p.X != pt.X || p.Y != pt.Y || p.H != pt.H || p.XYErr != pt.XYErr {
+			t.Fatalf("point %d: serial %+v, parallel %+v", i, pt, p)
+		}
+	}
+
+	// Check cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := batchTransformer(t, 1000, Options{Workers: 8}).TransformBatchContext(ctx); err != context.Canceled {
+		t.Errorf("expected %v, received %v", context.Canceled, err)
+	}
+}
+
+// Benchmark serial and parallel transformation
+func BenchmarkTransformBatch(b *testing.B) {
+
+	// Setup route
+	setupBatchRoute(b)
+
+	// Run with different numbers of workers
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tr := batchTransformer(b, 50000, Options{Workers: workers})
+				b.StartTimer()
+				if _, err := tr.TransformBatch(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Test propagation of stated accuracies
+func TestUncertainty(t *testing.T) {
+
+	// Setup app state with stated accuracies on all hops, except cs1 to cs4
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs2", "cs3", "cs4"},
+		ValidHSs: []string{"hs1", "hs2", "hs3"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {
+				"cs2": {{Type: CSTypeAffine, A10: 1, B01: 1, Accuracy: 0.3}},
+				"cs4": {{Type: CSTypeAffine, A10: 1, B01: 1}},
+			},
+			"cs2": {"cs3": {{Type: CSTypeAffine, A10: 1, B01: 1, Accuracy: 0.4}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypeGrid, Name: "geoid", Direction: -1, Accuracy: 1}},
+			"hs2": {"hs3": {Type: HSTypePlane, Name: "ptr", Direction: 1, Accuracy: 0.05}},
+		},
+		HTransformations: config.TransformationMethods{
+			Plane: map[string]config.HPlaneTransformation{"ptr": {A: 0.2}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid and accuracy grid after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs2", GridSize: 100}}
+	g := grids.New(0, 0, 100, 100, 0, 0, 2, 2)
+	acc := grids.New(0, 0, 100, 100, 0, 0, 2, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			g.Set(i, j, 40)
+			acc.Set(i, j, 0.12)
+		}
+	}
+	Repo.Grids = map[string]*grids.Grid{"geoid": g}
+	Repo.AccuracyGrids = map[string]*grids.Grid{"geoid": acc}
+
+	// Transform point
+	tr, err := GetTransformer("cs1", "cs3", "hs1", "hs3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.SetOptions(Options{Explain: true})
+	tr.Add(0, &PointResult{X: 50, Y: 50, H: 100, HasH: true})
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check that variances are added, with the accuracy grid replacing the stated accuracy
+	pt := res[0]
+	if !pt.HasSigmaXY || math.Abs(pt.SigmaXY-0.5) > 1e-9 {
+		t.Errorf("expected horizontal sigma 0.5, received %f %v", pt.SigmaXY, pt.HasSigmaXY)
+	}
+	if !pt.HasSigmaH || math.Abs(pt.SigmaH-0.13) > 1e-6 {
+		t.Errorf("expected vertical sigma 0.13, received %f %v", pt.SigmaH, pt.HasSigmaH)
+	}
+	if s := pt.Trace.HSHops[0].Sigma; s == nil || math.Abs(*s-0.12) > 1e-6 {
+		t.Errorf("expected traced grid sigma 0.12, received %v", s)
+	}
+
+	// Check that a hop without accuracy makes the estimate unknown
+	tr, err = GetTransformer("cs1", "cs4", "hs1", "hs1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Add(0, &PointResult{X: 50, Y: 50, H: 100, HasH: true})
+	res, err = tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res[0].HasSigmaXY || !res[0].HasSigmaH || res[0].SigmaH != 0 {
+		t.Errorf("expected unknown horizontal and zero vertical sigma, received %+v", res[0])
+	}
+}
+
+// Test explain trace of the path, zones and intermediate coordinates
+func TestExplain(t *testing.T) {
+
+	// Setup app state with a zone hop, an affine hop and plane and grid HS hops
+	app := config.App{
+		ValidCSs: []string{"cs1", "cs3"},
+		ValidHSs: []string{"hs1", "hs3"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {
+				{Name: "far", Border: squareBorder(1000, 0, 10), A10: 1, B01: 1},
+				{Name: "near", Border: squareBorder(0, 0, 100), A00: 10, A10: 1, B01: 1},
+			}},
+			"cs2": {"cs3": {{Type: CSTypeAffine, B00: 5, A10: 1, B01: 1}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypePlane, Name: "ptr", Direction: 1}},
+			"hs2": {"hs3": {Type: HSTypeGrid, Name: "geoid", Direction: -1}},
+		},
+		HTransformations: config.TransformationMethods{
+			Plane: map[string]config.HPlaneTransformation{"ptr": {A: 1.5}},
+		},
+	}
+	if err := Setup(&app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add grid in cs2 after setup, so no file is loaded
+	app.HTransformations.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs2"}}
+	g := grids.New(0, 0, 100, 100, 0, 0, 2, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			g.Set(i, j, 40)
+		}
+	}
+	Repo.Grids = map[string]*grids.Grid{"geoid": g}
+
+	// Transform with explain
+	tr, err := GetTransformer("cs1", "cs3", "hs1", "hs3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.SetOptions(Options{Explain: true})
+	tr.Add(0, &PointResult{X: 20, Y: 30, H: 100, HasH: true})
+	res, err := tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := res[0]
+	if pt.Trace == nil {
+		t.Fatal("expected trace")
+	}
+
+	// Check paths
+	if expected := map[string][]string{"cs1": {"cs2"}, "cs2": {"cs3"}}; !reflect.DeepEqual(pt.Trace.CSPath, expected) {
+		t.Errorf("expected CS path %v, received %v", expected, pt.Trace.CSPath)
+	}
+	if expected := []string{"hs1", "hs2", "hs3"}; !reflect.DeepEqual(pt.Trace.HSPath, expected) {
+		t.Errorf("expected HS path %v, received %v", expected, pt.Trace.HSPath)
+	}
+
+	// Check CS hops
+	expected := []CSHopTrace{
+		{From: "cs1", To: "cs2", Zone: 1, ZoneName: "near", X: 30, Y: 30, Z: 100},
+		{From: "cs2", To: "cs3", X: 30, Y: 35, Z: 100},
+	}
+	if !reflect.DeepEqual(pt.Trace.CSHops, expected) {
+		t.Errorf("expected CS hops %+v, received %+v", expected, pt.Trace.CSHops)
+	}
+
+	// Check grid system coordinates
+	if pt.Trace.BGS == nil || *pt.Trace.BGS != [2]float64{30, 30} {
+		t.Errorf("expected grid system coordinates 30 30, received %v", pt.Trace.BGS)
+	}
+
+	// Check HS hops
+	hs := pt.Trace.HSHops
+	if len(hs) != 2 || hs[0].Name != "ptr" || hs[0].Correction != 1.5 || hs[0].H != 101.5 ||
+		hs[1].Name != "geoid" || hs[1].Correction != -40 || hs[1].H != 61.5 || hs[1].Interpolation != grids.InterpolationBilinear {
+		t.Errorf("unexpected HS hops %+v", hs)
+	}
+	if pt.X != 30 || pt.Y != 35 || pt.H != 61.5 {
+		t.Errorf("expected 30 35 61.5, received %g %g %g", pt.X, pt.Y, pt.H)
+	}
+	if hs[1].Fallback {
+		t.Error("expected no interpolation fallback")
+	}
+
+	// Bicubic falls back to bilinear on the 2x2 grid
+	Repo.HSGraph.methods.Grid = map[string]config.HGridTransformation{"geoid": {CS: "cs2", Interpolation: grids.InterpolationBicubic}}
+	tr, err = GetTransformer("cs1", "cs3", "hs1", "hs3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.SetOptions(Options{Explain: true})
+	tr.Add(0, &PointResult{X: 20, Y: 30, H: 100, HasH: true})
+	res, err = tr.TransformBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt = res[0]; pt.H != 61.5 || len(pt.HErr) > 0 {
+		t.Errorf("expected fallback height 61.5, received %g %s", pt.H, pt.HErr)
+	}
+	if hs = pt.Trace.HSHops; len(hs) != 2 || hs[1].Interpolation != grids.InterpolationBilinear || !hs[1].Fallback {
+		t.Errorf("expected bilinear fallback in trace, received %+v", hs)
+	}
+}
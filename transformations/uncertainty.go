@@ -0,0 +1,67 @@
+package transformations
+
+import (
+	"math"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+)
+
+// Get standard deviation of a zone at point in meters
+// Returns false if the zone has no stated accuracy
+func zoneSigma(zone config.CSTransformation, x, y float64) (float64, bool) {
+
+	// Use stated accuracy
+	if zone.Accuracy > 0 {
+		return zone.Accuracy, true
+	}
+
+	switch zoneType(zone) {
+
+	// Conversions are exact
+	case CSTypeConversion:
+		return 0, true
+
+	// Use shift grid accuracies, given in arc seconds of latitude and longitude
+	case CSTypeNTv2:
+
+		// Get grid
+		g, err := ntv2Grid(zone)
+		if err != nil {
+			return 0, false
+		}
+
+		// Get accuracies, zero and negative values mean unknown
+		aLat, aLon, ok := g.Accuracy(x, y)
+		if !ok || aLat <= 0 || aLon <= 0 {
+			return 0, false
+		}
+
+		// Convert to meters
+		sLat := aLat * degreeLength / 3600
+		sLon := aLon * degreeLength * math.Cos(x*math.Pi/180) / 3600
+
+		return math.Hypot(sLat, sLon), true
+	}
+
+	return 0, false
+}
+
+// Get standard deviation of an HS hop at point in meters
+// Accuracy grids take precedence over the stated accuracy
+// Returns false if the hop has no accuracy
+func hopSigma(hop hsHop, x, y float64) (float64, bool) {
+
+	// Use accuracy grid
+	if hop.accuracy != nil {
+		if s, ok := hop.accuracy.Bilinear(x, y); ok {
+			return s, true
+		}
+	}
+
+	// Use stated accuracy
+	if hop.params.Accuracy > 0 {
+		return hop.params.Accuracy, true
+	}
+
+	return 0, false
+}
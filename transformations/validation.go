@@ -0,0 +1,583 @@
+package transformations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dimitargrozev5/bgstrans-2-api/config"
+	"github.com/dimitargrozev5/bgstrans-2-api/geometry"
+	"github.com/dimitargrozev5/bgstrans-2-api/grids"
+)
+
+// Configuration issue
+type Issue struct {
+	// Location of the issue in the configuration
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Format issue
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// Configuration validation report
+// Errors prevent the app from starting, warnings are only logged
+type ValidationReport struct {
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+// Add error
+func (r *ValidationReport) errorf(path, format string, args ...any) {
+	r.Errors = append(r.Errors, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Add warning
+func (r *ValidationReport) warnf(path, format string, args ...any) {
+	r.Warnings = append(r.Warnings, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Get report as an error, listing all errors
+// Returns nil if there are no errors
+func (r ValidationReport) Err() error {
+
+	// Skip valid configuration
+	if len(r.Errors) == 0 {
+		return nil
+	}
+
+	// List errors
+	lines := []string{fmt.Sprintf("%d configuration errors", len(r.Errors))}
+	for _, issue := range r.Errors {
+		lines = append(lines, "  "+issue.String())
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// Validate transformation configuration
+// Checks references between systems, transformations and grid files, zone borders,
+// and that all valid systems can reach each other
+func Validate(a *config.App) ValidationReport {
+
+	// Store report
+	var r ValidationReport
+
+	// Run checks
+	r.validateSystems(a)
+	r.validateCSGraph(a)
+	r.validateHSGraph(a)
+	r.validateGrids(a)
+	r.validateReachability(a)
+
+	return r
+}
+
+// Get sorted map keys
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get all nodes of a graph
+func graphNodes[T any](graph map[string]map[string]T) map[string]bool {
+	res := map[string]bool{}
+	for from, edges := range graph {
+		res[from] = true
+		for to := range edges {
+			res[to] = true
+		}
+	}
+	return res
+}
+
+// Get valid systems without duplicates, reporting duplicates
+func (r *ValidationReport) uniqueSystems(path string, systems []string) []string {
+
+	// Store result
+	seen := map[string]bool{}
+	var res []string
+
+	// Iterate over systems
+	for _, s := range systems {
+		if seen[s] {
+			r.warnf(path, "duplicate system %s", s)
+			continue
+		}
+		seen[s] = true
+		res = append(res, s)
+	}
+
+	return res
+}
+
+// Validate system definitions, ellipsoids and projections
+func (r *ValidationReport) validateSystems(a *config.App) {
+
+	// Check for duplicates
+	validCSs := r.uniqueSystems("validCSs", a.ValidCSs)
+	validHSs := r.uniqueSystems("validHSs", a.ValidHSs)
+
+	// Check descriptions
+	for _, cs := range sortedKeys(a.CSDescriptions) {
+		if !contains(validCSs, cs) {
+			r.warnf("csDescriptions."+cs, "description of a system, that is not valid")
+		}
+	}
+	for _, hs := range sortedKeys(a.HSDescriptions) {
+		if !contains(validHSs, hs) {
+			r.warnf("hsDescriptions."+hs, "description of a system, that is not valid")
+		}
+	}
+
+	// Check ellipsoids
+	for _, name := range sortedKeys(a.Ellipsoids) {
+		e := a.Ellipsoids[name]
+		if e.A <= 0 || e.InvF < 0 {
+			r.errorf("ellipsoids."+name, "semi-major axis must be positive and inverse flattening not negative")
+		}
+	}
+
+	// Check projections
+	for _, name := range sortedKeys(a.Projections) {
+		if m := a.Projections[name].Method; m != ProjectionLCC && m != ProjectionTM {
+			r.errorf("projections."+name, "unknown projection method '%s'", m)
+		}
+	}
+
+	// Check system definitions
+	for _, cs := range sortedKeys(a.CSDefinitions) {
+
+		// Get definition
+		def := a.CSDefinitions[cs]
+		path := "csDefinitions." + cs
+
+		// Check kind
+		kind := def.Kind
+		if kind == "" {
+			kind = KindProjected
+		}
+		if kind != KindProjected && kind != KindGeographic && kind != KindGeocentric {
+			r.errorf(path, "unknown system kind '%s'", def.Kind)
+			continue
+		}
+
+		// Check projection
+		if kind == KindProjected && def.Projection != "" {
+			if _, ok := a.Projections[def.Projection]; !ok {
+				r.errorf(path, "unknown projection '%s'", def.Projection)
+			}
+		}
+		if kind != KindProjected && def.Projection != "" {
+			r.warnf(path, "projection is not used by %s systems", kind)
+		}
+
+		// Check ellipsoid of systems, that need one
+		if kind != KindProjected || def.Projection != "" {
+			_, predefined := defaultEllipsoids[def.Ellipsoid]
+			_, configured := a.Ellipsoids[def.Ellipsoid]
+			if !predefined && !configured {
+				r.errorf(path, "unknown ellipsoid '%s'", def.Ellipsoid)
+			}
+		}
+	}
+}
+
+// Check if list contains value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Get kind of a system from configuration
+func configKind(a *config.App, cs string) string {
+	if kind := a.CSDefinitions[cs].Kind; kind != "" {
+		return kind
+	}
+	return KindProjected
+}
+
+// Validate CS graph edges and zones
+func (r *ValidationReport) validateCSGraph(a *config.App) {
+
+	// Check that valid systems are in the graph
+	nodes := graphNodes(a.CsGraph)
+	if len(a.ValidCSs) > 1 {
+		for _, cs := range a.ValidCSs {
+			if !nodes[cs] {
+				r.errorf("validCSs."+cs, "system is not in csGraph")
+			}
+		}
+	}
+
+	// Iterate over edges
+	for _, from := range sortedKeys(a.CsGraph) {
+		for _, to := range sortedKeys(a.CsGraph[from]) {
+
+			// Get edge
+			zones := a.CsGraph[from][to]
+			path := fmt.Sprintf("csGraph.%s.%s", from, to)
+
+			// Check edge
+			if from == to {
+				r.errorf(path, "edge starts and ends in the same system")
+			}
+			if len(zones) == 0 {
+				r.errorf(path, "edge has no transformation zones")
+				continue
+			}
+
+			// Check for reverse edge
+			if _, ok := a.CsGraph[to][from]; !ok {
+				invertible := false
+				for _, zone := range zones {
+					invertible = invertible || zone.Invertible
+				}
+				if !invertible {
+					r.warnf(path, "one-directional edge, %s can't be transformed to %s", to, from)
+				}
+			}
+
+			// Check zones
+			for i, zone := range zones {
+				r.validateZone(a, fmt.Sprintf("%s[%d]", path, i), from, to, zone)
+			}
+		}
+	}
+}
+
+// Validate CS transformation zone
+func (r *ValidationReport) validateZone(a *config.App, path, from, to string, zone config.CSTransformation) {
+
+	// Check method parameters
+	switch zoneType(zone) {
+	case CSTypePolynomial:
+		if len(zone.Border) == 0 {
+			r.errorf(path, "polynomial zone has no border")
+		}
+	case CSTypeAffine, CSTypeConformal:
+	case CSTypeHelmert:
+		if zone.Convention != "" && zone.Convention != PositionVector && zone.Convention != CoordinateFrame {
+			r.errorf(path, "unknown helmert convention '%s'", zone.Convention)
+		}
+	case CSTypeNTv2:
+		if _, ok := a.ShiftGrids[zone.Grid]; !ok {
+			r.errorf(path, "unknown shift grid '%s'", zone.Grid)
+		}
+	case CSTypeConversion:
+		if configKind(a, from) == configKind(a, to) {
+			r.errorf(path, "conversion between two %s systems", configKind(a, from))
+		}
+	default:
+		r.errorf(path, "unknown transformation type '%s'", zone.Type)
+	}
+
+	// Check accuracy
+	if zone.Accuracy < 0 {
+		r.errorf(path, "accuracy must not be negative")
+	}
+
+	// Check border
+	r.validateBorder(path, zone)
+}
+
+// Validate zone border polygons
+func (r *ValidationReport) validateBorder(path string, zone config.CSTransformation) {
+
+	// Skip zones without border
+	if len(zone.Border) == 0 {
+		if len(zone.Holes) > 0 || len(zone.Parts) > 0 {
+			r.errorf(path, "holes and parts require a border")
+		}
+		return
+	}
+
+	// Check polygons
+	r.validatePolygon(path, "", zone.Border, zone.Holes)
+	for i, part := range zone.Parts {
+		r.validatePolygon(path, fmt.Sprintf("part %d ", i), part.Border, part.Holes)
+	}
+}
+
+// Validate polygon rings and check that holes are inside the border
+func (r *ValidationReport) validatePolygon(path, prefix string, border config.Ring, holes []config.Ring) {
+
+	// Check border
+	outer, ok := r.validateRing(path, prefix+"border", border)
+
+	// Check holes
+	for i, hole := range holes {
+		name := fmt.Sprintf("%shole %d", prefix, i)
+		h, holeOK := r.validateRing(path, name, hole)
+		if !ok || !holeOK {
+			continue
+		}
+
+		// Check that hole vertices are not outside of the border
+		for _, p := range h {
+			if outer.Locate(p[0], p[1], 0) == geometry.Outside {
+				r.errorf(path, "%s is not inside the border", name)
+				break
+			}
+		}
+	}
+}
+
+// Validate polygon ring
+// Returns the ring without a closing vertex, and false if it is invalid
+func (r *ValidationReport) validateRing(path, name string, ring config.Ring) (geometry.Ring, bool) {
+
+	// Get vertices, without a closing vertex
+	pts := ring.Geometry()
+	if n := len(pts); n > 1 && pts[0] == pts[n-1] {
+		pts = pts[:n-1]
+	}
+	n := len(pts)
+
+	// Check number of vertices
+	if n < 3 {
+		r.errorf(path, "%s has %d vertices, at least 3 are required", name, n)
+		return nil, false
+	}
+
+	// Check coordinates
+	for i, p := range pts {
+		if math.IsNaN(p[0]) || math.IsNaN(p[1]) || math.IsInf(p[0], 0) || math.IsInf(p[1], 0) {
+			r.errorf(path, "%s vertex %d is not a finite number", name, i)
+			return nil, false
+		}
+	}
+
+	// Check that edges intersect only their neighbours
+	for i := 0; i < n; i++ {
+		a, b := pts[i], pts[(i+1)%n]
+		for j := i + 2; j < n; j++ {
+
+			// Skip edges, that share the first vertex
+			if i == 0 && j == n-1 {
+				continue
+			}
+
+			// Check intersection
+			c, d := pts[j], pts[(j+1)%n]
+			if geometry.SegmentsIntersect(a[0], a[1], b[0], b[1], c[0], c[1], d[0], d[1]) {
+				r.errorf(path, "%s edges %d and %d intersect", name, i, j)
+				return nil, false
+			}
+		}
+	}
+
+	// Check area
+	area := 0.0
+	for i := range pts {
+		j := (i + 1) % n
+		area += pts[i][0]*pts[j][1] - pts[j][0]*pts[i][1]
+	}
+	if area == 0 {
+		r.errorf(path, "%s has no area", name)
+		return nil, false
+	}
+
+	return pts, true
+}
+
+// Validate HS graph edges and their methods
+func (r *ValidationReport) validateHSGraph(a *config.App) {
+
+	// Check that valid systems are in the graph
+	nodes := graphNodes(a.HsGraph)
+	if len(a.ValidHSs) > 1 {
+		for _, hs := range a.ValidHSs {
+			if !nodes[hs] {
+				r.errorf("validHSs."+hs, "system is not in hsGraph")
+			}
+		}
+	}
+
+	// Track used methods
+	used := map[string]bool{}
+
+	// Iterate over edges
+	for _, from := range sortedKeys(a.HsGraph) {
+		for _, to := range sortedKeys(a.HsGraph[from]) {
+
+			// Get edge
+			edge := a.HsGraph[from][to]
+			path := fmt.Sprintf("hsGraph.%s.%s", from, to)
+
+			// Check edge
+			if from == to {
+				r.errorf(path, "edge starts and ends in the same system")
+			}
+			if _, ok := a.HsGraph[to][from]; !ok {
+				r.warnf(path, "one-directional edge, %s can't be transformed to %s", to, from)
+			}
+
+			// Check method
+			switch edge.Type {
+			case HSTypeGrid:
+				if _, ok := a.HTransformations.Grid[edge.Name]; !ok {
+					r.errorf(path, "unknown grid transformation '%s'", edge.Name)
+				}
+			case HSTypePlane:
+				if _, ok := a.HTransformations.Plane[edge.Name]; !ok {
+					r.errorf(path, "unknown plane transformation '%s'", edge.Name)
+				}
+			default:
+				r.errorf(path, "unknown transformation type '%s'", edge.Type)
+			}
+			used[edge.Type+"."+edge.Name] = true
+
+			// Check parameters
+			if edge.Direction != 1 && edge.Direction != -1 {
+				r.errorf(path, "direction must be 1 or -1")
+			}
+			if edge.Accuracy < 0 {
+				r.errorf(path, "accuracy must not be negative")
+			}
+		}
+	}
+
+	// Report unused methods
+	for _, name := range sortedKeys(a.HTransformations.Grid) {
+		if !used[HSTypeGrid+"."+name] {
+			r.warnf("hTransformations.gridTransformations."+name, "transformation is not used in hsGraph")
+		}
+	}
+	for _, name := range sortedKeys(a.HTransformations.Plane) {
+		if !used[HSTypePlane+"."+name] {
+			r.warnf("hTransformations.planeTransformations."+name, "transformation is not used in hsGraph")
+		}
+	}
+}
+
+// Check that a grid file exists
+func (r *ValidationReport) checkFile(path, dir, file string) {
+	if file == "" {
+		r.errorf(path, "no file")
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+		r.errorf(path, "file %s does not exist", filepath.Join(dir, file))
+	}
+}
+
+// Validate grid transformations and shift grids
+func (r *ValidationReport) validateGrids(a *config.App) {
+
+	// Get grid models directory
+	dir := gridPath(a)
+
+	// Track systems in the CS graph
+	nodes := graphNodes(a.CsGraph)
+
+	// Iterate over grid transformations
+	for _, name := range sortedKeys(a.HTransformations.Grid) {
+
+		// Get grid
+		params := a.HTransformations.Grid[name]
+		path := "hTransformations.gridTransformations." + name
+
+		// Check files
+		r.checkFile(path+".DB", dir, params.DB)
+		if params.AccuracyDB != "" {
+			r.checkFile(path+".AccuracyDB", dir, params.AccuracyDB)
+		}
+
+		// Check parameters
+		switch params.Format {
+		case "", GridFormatSQLite, GridFormatBinary, GridFormatGTX, GridFormatISG:
+		default:
+			r.errorf(path, "unsupported format '%s'", params.Format)
+		}
+		if params.GridSize <= 0 || params.GridSizeY < 0 {
+			r.errorf(path, "grid size must be positive")
+		}
+		if err := grids.ValidInterpolation(gridMethod(params)); err != nil {
+			r.errorf(path, "%v", err)
+		}
+
+		// Check grid system
+		if cs := gridSystem(params); !nodes[cs] {
+			r.errorf(path, "grid system %s is not in csGraph", cs)
+		}
+	}
+
+	// Track used shift grids
+	used := map[string]bool{}
+	for _, edges := range a.CsGraph {
+		for _, zones := range edges {
+			for _, zone := range zones {
+				used[zone.Grid] = true
+			}
+		}
+	}
+
+	// Iterate over shift grids
+	for _, name := range sortedKeys(a.ShiftGrids) {
+		path := "shiftGrids." + name
+		r.checkFile(path+".File", dir, a.ShiftGrids[name].File)
+		if !used[name] {
+			r.warnf(path, "shift grid is not used in csGraph")
+		}
+	}
+}
+
+// Validate that all valid systems can reach each other
+func (r *ValidationReport) validateReachability(a *config.App) {
+
+	// Get CS targets, including grid systems of HS transformations
+	csTargets := append([]string{}, a.ValidCSs...)
+	for _, name := range sortedKeys(a.HTransformations.Grid) {
+		if cs := gridSystem(a.HTransformations.Grid[name]); !contains(csTargets, cs) {
+			csTargets = append(csTargets, cs)
+		}
+	}
+
+	// Check systems
+	checkReachable(r, "validCSs", deriveInverses(a.CsGraph), a.ValidCSs, csTargets)
+	checkReachable(r, "validHSs", a.HsGraph, a.ValidHSs, a.ValidHSs)
+}
+
+// Check that every target can be reached from every system
+func checkReachable[T any](r *ValidationReport, path string, graph map[string]map[string]T, systems, targets []string) {
+
+	// Iterate over systems
+	checked := map[string]bool{}
+	for _, from := range systems {
+
+		// Skip duplicates
+		if checked[from] {
+			continue
+		}
+		checked[from] = true
+
+		// Get unreachable targets, where edge costs don't matter
+		dists := distGraph(graph, from, 1)
+		var missing []string
+		for _, to := range targets {
+			if _, ok := dists[to]; !ok && to != from && !contains(missing, to) {
+				missing = append(missing, to)
+			}
+		}
+
+		// Report
+		if len(missing) > 0 {
+			r.errorf(path+"."+from, "can't reach %s", strings.Join(missing, ", "))
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package transformations
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/dimitargrozev5/bgstrans-2-api/config"
@@ -13,6 +17,14 @@ func TestValidation(t *testing.T) {
 	app := config.App{
 		ValidCSs: []string{"cs1", "cs2"},
 		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {}},
+			"cs2": {"cs1": {}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: "plane", Name: "ptr12", Direction: 1}},
+			"hs2": {"hs1": {Type: "plane", Name: "ptr12", Direction: -1}},
+		},
 	}
 
 	// Setup transformations
@@ -48,3 +60,102 @@ func TestValidation(t *testing.T) {
 		t.Error("expected error for invalid ohs")
 	}
 }
+
+// Border of a square zone
+func squareBorder(x0, y0, size float64) config.Ring {
+	return config.Ring{{X: x0, Y: y0}, {X: x0, Y: y0 + size}, {X: x0 + size, Y: y0 + size}, {X: x0 + size, Y: y0}}
+}
+
+// Test configuration validation report
+func TestValidateConfig(t *testing.T) {
+
+	// Create grid file
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "geoid.db"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create valid configuration
+	app := config.App{
+		GridPath: dir,
+		ValidCSs: []string{"cs1", "cs2"},
+		ValidHSs: []string{"hs1", "hs2"},
+		CsGraph: map[string]map[string][]config.CSTransformation{
+			"cs1": {"cs2": {{Border: squareBorder(0, 0, 10)}}},
+			"cs2": {"cs1": {{Type: CSTypeAffine, A10: 1, B01: 1}}},
+		},
+		HsGraph: map[string]map[string]config.HSTransformation{
+			"hs1": {"hs2": {Type: HSTypeGrid, Name: "geoid", Direction: 1}},
+			"hs2": {"hs1": {Type: HSTypeGrid, Name: "geoid", Direction: -1}},
+		},
+		HTransformations: config.TransformationMethods{
+			Grid: map[string]config.HGridTransformation{"geoid": {DB: "geoid.db", CS: "cs2", GridSize: 100}},
+		},
+	}
+
+	// Check valid configuration
+	report := Validate(&app)
+	if err := report.Err(); err != nil || len(report.Warnings) > 0 {
+		t.Fatalf("expected no issues, received %v %v", err, report.Warnings)
+	}
+
+	// Break configuration
+	app.ValidCSs = append(app.ValidCSs, "cs3")
+	app.CsGraph["cs1"]["cs2"][0].Border = app.CsGraph["cs1"]["cs2"][0].Border[:2]
+	app.HsGraph["hs2"]["hs1"] = config.HSTransformation{Type: HSTypePlane, Name: "missing", Direction: -1}
+	app.HTransformations.Grid["geoid"] = config.HGridTransformation{DB: "missing.db", CS: "cs2", GridSize: 100}
+	delete(app.CsGraph, "cs2")
+
+	// Check reported issues
+	report = Validate(&app)
+	expected := []Issue{
+		{"validCSs.cs3", "system is not in csGraph"},
+		{"csGraph.cs1.cs2[0]", "border has 2 vertices, at least 3 are required"},
+		{"hsGraph.hs2.hs1", "unknown plane transformation 'missing'"},
+		{"hTransformations.gridTransformations.geoid.DB", fmt.Sprintf("file %s does not exist", filepath.Join(dir, "missing.db"))},
+		{"validCSs.cs1", "can't reach cs3"},
+		{"validCSs.cs2", "can't reach cs1, cs3"},
+	}
+	for _, issue := range expected {
+		if !slices.Contains(report.Errors, issue) {
+			t.Errorf("expected error %s, received %v", issue, report.Errors)
+		}
+	}
+	if !slices.Contains(report.Warnings, Issue{"csGraph.cs1.cs2", "one-directional edge, cs2 can't be transformed to cs1"}) {
+		t.Errorf("expected one-directional edge warning, received %v", report.Warnings)
+	}
+	if report.Err() == nil {
+		t.Error("expected error")
+	}
+}
+
+// Test border polygon checks
+func TestValidateBorder(t *testing.T) {
+
+	// Create zones
+	bowtie := squareBorder(0, 0, 10)
+	bowtie[2], bowtie[3] = bowtie[3], bowtie[2]
+	line := squareBorder(0, 0, 10)[:3]
+	line[1].X, line[1].Y, line[2].X, line[2].Y = 5, 5, 10, 10
+	closed := append(squareBorder(0, 0, 10), squareBorder(0, 0, 10)[0])
+
+	// Check messages
+	for _, c := range []struct {
+		zone     config.CSTransformation
+		expected string
+	}{
+		{config.CSTransformation{Border: bowtie}, "border edges 1 and 3 intersect"},
+		{config.CSTransformation{Border: line}, "border has no area"},
+		{config.CSTransformation{Border: closed}, ""},
+		{config.CSTransformation{Border: closed, Holes: []config.Ring{squareBorder(2, 2, 6)}}, ""},
+		{config.CSTransformation{Border: closed, Holes: []config.Ring{squareBorder(5, 5, 10)}}, "hole 0 is not inside the border"},
+		{config.CSTransformation{Border: closed, Parts: []config.BorderPart{{Border: bowtie}}}, "part 0 border edges 1 and 3 intersect"},
+		{config.CSTransformation{Holes: []config.Ring{closed}}, "holes and parts require a border"},
+	} {
+		var r ValidationReport
+		r.validateBorder("zone", c.zone)
+		if (c.expected == "" && len(r.Errors) > 0) || (c.expected != "" && (len(r.Errors) != 1 || r.Errors[0].Message != c.expected)) {
+			t.Errorf("expected '%s', received %v", c.expected, r.Errors)
+		}
+	}
+}